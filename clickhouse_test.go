@@ -4,7 +4,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
 	"github.com/AlxBystrov/go-lucene/pkg/driverclick"
+	"github.com/AlxBystrov/go-lucene/pkg/fuzzy"
 )
 
 func TestClickhouseSQLEndToEnd(t *testing.T) {
@@ -51,6 +53,50 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 			input: "a:<=22 AND b:>=33",
 			want:  `(numbers.value[indexOf(numbers.name,'a')] <= 22) AND (numbers.value[indexOf(numbers.name,'b')] >= 33)`,
 		},
+		"float_equal": {
+			input: "a:1.5",
+			want:  `numbers.value[indexOf(numbers.name,'a')] = 1.5`,
+		},
+		"float_greater_is_not_dropped": {
+			input: "a:>1.5 AND b:x",
+			want:  `(numbers.value[indexOf(numbers.name,'a')] > 1.5) AND (lowerUTF8(strings.value[indexOf(strings.name,'b')]) like lowerUTF8('%x%'))`,
+		},
+		"string_greater": {
+			input: "a:>abc",
+			want:  `strings.value[indexOf(strings.name,'a')] > 'abc'`,
+		},
+		"wildcard_greater_is_an_error": {
+			input: "a:>ab*",
+			err:   "unable to render operator [GREATER] on the wildcard value ab*",
+		},
+		"number_list": {
+			input: "a:(1 OR 2.5)",
+			want:  `numbers.value[indexOf(numbers.name,'a')] IN (1, 2.5)`,
+		},
+		"mixed_list_is_compared_as_strings": {
+			input: "a:(1 OR b)",
+			want:  `strings.value[indexOf(strings.name,'a')] IN ('1', 'b')`,
+		},
+		"wildcard_in_group_is_an_error": {
+			input: "a:(b* OR c)",
+			err:   "unable to render b* OR c as a value",
+		},
+		"float_range": {
+			input: "a:[1.5 TO 2.25]",
+			want:  `numbers.value[indexOf(numbers.name,'a')] >= 1.5 AND numbers.value[indexOf(numbers.name,'a')] <= 2.25`,
+		},
+		"half_open_string_range": {
+			input: "a:{foo TO *}",
+			want:  `strings.value[indexOf(strings.name,'a')] > 'foo'`,
+		},
+		"unbounded_range_is_an_error": {
+			input: "a:[* TO *]",
+			err:   "without any bound",
+		},
+		"backslash_in_value": {
+			input: `a:"b\c"`,
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('%b\\\\c%')`,
+		},
 		"basic_wild_equal_with_*": {
 			input: "a:b*",
 			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('b%')`,
@@ -59,6 +105,14 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 			input: "a:b?z",
 			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('b_z')`,
 		},
+		"wild_escapes_like_symbols": {
+			input: "path:50%_off*",
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'path')]) like lowerUTF8('50\\%\\_off%')`,
+		},
+		"wild_with_escaped_wildcards": {
+			input: `a:b\*c?\?`,
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('b*c_?')`,
+		},
 		"basic_inclusive_range": {
 			input: "a:[* TO 5]",
 			want:  `numbers.value[indexOf(numbers.name,'a')] <= 5`,
@@ -69,7 +123,11 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 		},
 		"range_over_strings": {
 			input: "a:{foo TO bar}",
-			want:  `strings.value[indexOf(strings.name,'a')] BETWEEN 'foo' AND 'bar'`,
+			want:  `strings.value[indexOf(strings.name,'a')] > 'foo' AND strings.value[indexOf(strings.name,'a')] < 'bar'`,
+		},
+		"inclusive_range_over_strings": {
+			input: "a:[x TO z]",
+			want:  `strings.value[indexOf(strings.name,'a')] BETWEEN 'x' AND 'z'`,
 		},
 		"basic_fuzzy": {
 			input: "b AND a~",
@@ -89,15 +147,31 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 		},
 		"regexp": {
 			input: "a:/b [c]/",
-			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'a')]),lowerUTF8('b [c]'))`,
+			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'a')]),lowerUTF8('^(?s:b [c])$'))`,
 		},
 		"regexp_with_keywords": {
 			input: `a:/b "[c]/`,
-			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'a')]),lowerUTF8('b "[c]'))`,
+			err:   `expected '"'`,
+		},
+		"regexp_with_quoted_keywords": {
+			input: `a:/b "[c]"/`,
+			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'a')]),lowerUTF8('^(?s:b \\[c\\])$'))`,
 		},
 		"regexp_with_escaped_chars": {
 			input: `url:/example.com\/foo\/bar\/.*/`,
-			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'url')]),lowerUTF8('example.com\/foo\/bar\/.*'))`,
+			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'url')]),lowerUTF8('^(?s:example.com/foo/bar/.*)$'))`,
+		},
+		"regexp_lucene_syntax": {
+			input: `status:/5<0-3>\d|@\.err/`,
+			want:  `match(lowerUTF8(strings.value[indexOf(strings.name,'status')]),lowerUTF8('^(?s:5[0-3][0-9]|.*\\.err)$'))`,
+		},
+		"regexp_on_source": {
+			input: `_source:/.*time'?out.*/`,
+			want:  `match(lowerUTF8(_source),lowerUTF8('^(?s:.*time''?out.*)$'))`,
+		},
+		"regexp_intersection_is_an_error": {
+			input: `a:/b.*&.*c/`,
+			err:   "the intersection & at position 3 has no equivalent",
 		},
 		"basic_default_AND": {
 			input: "a b",
@@ -133,7 +207,7 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 		},
 		"range_operator_exclusive": {
 			input: `a:{"ab" TO "az"}`,
-			want:  `strings.value[indexOf(strings.name,'a')] BETWEEN 'ab' AND 'az'`,
+			want:  `strings.value[indexOf(strings.name,'a')] > 'ab' AND strings.value[indexOf(strings.name,'a')] < 'az'`,
 		},
 		"range_operator_exclusive_unbound": {
 			input: `a:{2 TO *}`,
@@ -167,6 +241,14 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 			input: "d:e AND (-a:b AND +f:e)",
 			want:  `(lowerUTF8(strings.value[indexOf(strings.name,'d')]) like lowerUTF8('e')) AND ((NOT(lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('b'))) AND (lowerUTF8(strings.value[indexOf(strings.name,'f')]) like lowerUTF8('e')))`,
 		},
+		"boolean_query_should_is_optional": {
+			input: "+a:1 b:2 -c:3",
+			want:  `(numbers.value[indexOf(numbers.name,'a')] = 1) AND (NOT(numbers.value[indexOf(numbers.name,'c')] = 3))`,
+		},
+		"boolean_query_one_should_without_must": {
+			input: "a:1 b:2 -c:3",
+			want:  `((numbers.value[indexOf(numbers.name,'a')] = 1) OR (numbers.value[indexOf(numbers.name,'b')] = 2)) AND (NOT(numbers.value[indexOf(numbers.name,'c')] = 3))`,
+		},
 		"basic_escaping": {
 			input: `a:\(1\+1\)\:2`,
 			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('(1+1):2')`,
@@ -231,6 +313,10 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 			input: "1a:b",
 			want:  `lowerUTF8(strings.value[indexOf(strings.name,'1a')]) like lowerUTF8('b')`,
 		},
+		"fuzzy_needs_expansion_or_a_renderer": {
+			input: "a:foo~2",
+			err:   "expand the fuzzy terms first",
+		},
 	}
 
 	for name, tc := range tcs {
@@ -259,3 +345,495 @@ func TestClickhouseSQLEndToEnd(t *testing.T) {
 		})
 	}
 }
+
+func TestClickhouseFuzzy(t *testing.T) {
+	type tc struct {
+		input string
+		fuzzy driverclick.FuzzyRenderFN
+		dict  fuzzy.TermDictionary
+		want  string
+		err   string
+	}
+
+	dict := fuzzy.StaticDictionary{"service": {"checkout", "Checkout", "chekout", "cart", "catalog"}}
+
+	tcs := map[string]tc{
+		"expanded": {
+			input: "service:chekcout~1",
+			dict:  dict,
+			want:  `strings.value[indexOf(strings.name,'service')] IN ('Checkout', 'checkout', 'chekout')`,
+		},
+		"expanded_without_matches": {
+			input: "service:payments~2",
+			dict:  dict,
+			want:  `strings.value[indexOf(strings.name,'service')] IN ('payments')`,
+		},
+		"edit_distance": {
+			input: "service:chekcout~2 AND status:500",
+			fuzzy: driverclick.EditDistanceFuzzy,
+			want:  `(editDistanceUTF8(lowerUTF8(strings.value[indexOf(strings.name,'service')]), lowerUTF8('chekcout')) <= 2) AND (numbers.value[indexOf(numbers.name,'status')] = 500)`,
+		},
+		"ngram_distance": {
+			input: "_source:chekcout~",
+			fuzzy: driverclick.NgramDistanceFuzzy(0.3),
+			want:  `ngramDistanceCaseInsensitiveUTF8(_source, 'chekcout') <= 0.3`,
+		},
+		"bare_term": {
+			input: "chekcout~",
+			fuzzy: driverclick.EditDistanceFuzzy,
+			err:   "without a field",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.dict != nil {
+				e, err = fuzzy.Expand(e, tc.dict)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			driver := driverclick.NewClickhouseDriver()
+			driver.Fuzzy = tc.fuzzy
+			got, err := driver.Render(e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestClickhouseLayouts(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []driverclick.ClickhouseOpt
+		want  string
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"default_arrays": {
+			input: "service:checkout AND status:>=500",
+			want:  `(lowerUTF8(strings.value[indexOf(strings.name,'service')]) like lowerUTF8('%checkout%')) AND (numbers.value[indexOf(numbers.name,'status')] >= 500)`,
+		},
+		"custom_arrays": {
+			input: "service:checkout AND status:>=500",
+			opts: []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.ArrayLayout{
+				Strings: driverclick.Arrays{Names: "string_keys", Values: "string_values"},
+				Numbers: driverclick.Arrays{Names: "number_keys", Values: "number_values"},
+			})},
+			want: `(lowerUTF8(string_values[indexOf(string_keys,'service')]) like lowerUTF8('%checkout%')) AND (number_values[indexOf(number_keys,'status')] >= 500)`,
+		},
+		"native_columns": {
+			input: "service:checkout AND status:[500 TO 599]",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.NativeLayout{})},
+			want:  "(lowerUTF8(`service`) like lowerUTF8('%checkout%')) AND (`status` >= 500 AND `status` <= 599)",
+		},
+		"map_column": {
+			input: "service:checkout AND status:500",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.MapLayout{Column: "attributes"})},
+			want:  `(lowerUTF8(attributes['service']) like lowerUTF8('%checkout%')) AND (toFloat64OrNull(attributes['status']) = 500)`,
+		},
+		"json_column": {
+			input: "service:check* AND http.status:>=500",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.JSONLayout{Column: "attributes"})},
+			want:  "(lowerUTF8(attributes.`service`::String) like lowerUTF8('check%')) AND (attributes.`http`.`status`::Float64 >= 500)",
+		},
+		"nested_column": {
+			input: "service:(cart OR checkout) AND status:(500 OR 503)",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.NestedLayout{Column: "attributes"})},
+			want:  `(attributes.value[indexOf(attributes.key,'service')] IN ('cart', 'checkout')) AND (toFloat64OrNull(attributes.value[indexOf(attributes.key,'status')]) IN (500, 503))`,
+		},
+		"nested_column_with_subcolumns": {
+			input: "service:checkout",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.NestedLayout{Column: "tags", Key: "name", Value: "val"})},
+			want:  `lowerUTF8(tags.val[indexOf(tags.name,'service')]) like lowerUTF8('%checkout%')`,
+		},
+		"text_column": {
+			input: "_source:timeout OR _source:/conn.*refused/",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithTextColumn("body")},
+			want:  `(lowerUTF8(body) like lowerUTF8('%timeout%')) OR (match(lowerUTF8(body),lowerUTF8('^(?s:conn.*refused)$')))`,
+		},
+		"field_columns": {
+			input: "service:checkout AND level:error AND status:500",
+			opts: []driverclick.ClickhouseOpt{
+				driverclick.WithLayout(driverclick.MapLayout{Column: "attributes"}),
+				driverclick.WithFieldColumn("service", "service_name"),
+				driverclick.WithFieldColumn("status", "http_status"),
+			},
+			want: `((lowerUTF8(service_name) like lowerUTF8('%checkout%')) AND (lowerUTF8(attributes['level']) like lowerUTF8('%error%'))) AND (http_status = 500)`,
+		},
+		"fuzzy_with_layout": {
+			input: "service:chekcout~1",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithLayout(driverclick.MapLayout{Column: "attributes"})},
+			err:   "expand the fuzzy terms first",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driverclick.NewClickhouseDriver(tc.opts...).Render(e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestClickhouseMaterializedColumns(t *testing.T) {
+	type tc struct {
+		input            string
+		wantArrays       string
+		wantMaterialized string
+	}
+
+	materialized := driverclick.WithMaterializedColumns(map[string]string{
+		"service":  "service",
+		"level":    "level",
+		"trace_id": "trace_id",
+		"duration": "duration_ms",
+	})
+
+	tcs := map[string]tc{
+		"term": {
+			input:            "service:checkout",
+			wantArrays:       `lowerUTF8(strings.value[indexOf(strings.name,'service')]) like lowerUTF8('%checkout%')`,
+			wantMaterialized: `service = 'checkout'`,
+		},
+		"promoted_and_other_fields": {
+			input:            "service:checkout AND level:error AND http.method:GET",
+			wantArrays:       `((lowerUTF8(strings.value[indexOf(strings.name,'service')]) like lowerUTF8('%checkout%')) AND (lowerUTF8(strings.value[indexOf(strings.name,'level')]) like lowerUTF8('%error%'))) AND (lowerUTF8(strings.value[indexOf(strings.name,'http.method')]) like lowerUTF8('%GET%'))`,
+			wantMaterialized: `((service = 'checkout') AND (level = 'error')) AND (lowerUTF8(strings.value[indexOf(strings.name,'http.method')]) like lowerUTF8('%GET%'))`,
+		},
+		"list": {
+			input:            "level:(warn OR error)",
+			wantArrays:       `strings.value[indexOf(strings.name,'level')] IN ('warn', 'error')`,
+			wantMaterialized: `level IN ('warn', 'error')`,
+		},
+		"wildcard": {
+			input:            "trace_id:4bf92f35*",
+			wantArrays:       `lowerUTF8(strings.value[indexOf(strings.name,'trace_id')]) like lowerUTF8('4bf92f35%')`,
+			wantMaterialized: `trace_id like '4bf92f35%'`,
+		},
+		"regexp": {
+			input:            "service:/check(out|in)/",
+			wantArrays:       `match(lowerUTF8(strings.value[indexOf(strings.name,'service')]),lowerUTF8('^(?s:check(?:out|in))$'))`,
+			wantMaterialized: `match(service,'^(?s:check(?:out|in))$')`,
+		},
+		"range": {
+			input:            "duration:[100 TO *] AND NOT level:debug",
+			wantArrays:       `(numbers.value[indexOf(numbers.name,'duration')] >= 100) AND (NOT(lowerUTF8(strings.value[indexOf(strings.name,'level')]) like lowerUTF8('%debug%')))`,
+			wantMaterialized: `(duration_ms >= 100) AND (NOT(level = 'debug'))`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driverclick.NewClickhouseDriver().Render(e)
+			if err != nil {
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if got != tc.wantArrays {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.wantArrays, got, e)
+			}
+
+			got, err = driverclick.NewClickhouseDriver(materialized).Render(e)
+			if err != nil {
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if got != tc.wantMaterialized {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.wantMaterialized, got, e)
+			}
+		})
+	}
+}
+
+func TestClickhouseMatchModes(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []driverclick.ClickhouseOpt
+		want  string
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"like_by_default": {
+			input: "status:ok",
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'status')]) like lowerUTF8('%ok%')`,
+		},
+		"like_escapes_wildcards": {
+			input: `path:"50%_off"`,
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'path')]) like lowerUTF8('%50\\%\\_off%')`,
+		},
+		"exact": {
+			input: "status:ok",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Exact)},
+			want:  `strings.value[indexOf(strings.name,'status')] = 'ok'`,
+		},
+		"case_insensitive_exact": {
+			input: "status:OK",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.ExactCaseInsensitive)},
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'status')]) = lowerUTF8('OK')`,
+		},
+		"prefix": {
+			input: `path:"/api/v1"`,
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Prefix)},
+			want:  `startsWith(strings.value[indexOf(strings.name,'path')], '/api/v1')`,
+		},
+		"substring": {
+			input: `message:"50%_off"`,
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Substring)},
+			want:  `positionCaseInsensitiveUTF8(strings.value[indexOf(strings.name,'message')], '50%_off') > 0`,
+		},
+		"token": {
+			input: "message:timeout",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Token)},
+			want:  `hasToken(strings.value[indexOf(strings.name,'message')], 'timeout')`,
+		},
+		"token_with_separators_is_an_error": {
+			input: `message:"connection reset"`,
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Token)},
+			err:   "unable to match 'connection reset' on message as a token",
+		},
+		"per_field": {
+			input: "status:ok AND message:timeout AND path:api",
+			opts: []driverclick.ClickhouseOpt{
+				driverclick.WithMatchMode(driverclick.Substring),
+				driverclick.WithFieldMatchMode("status", driverclick.Exact),
+				driverclick.WithFieldMatchMode("message", driverclick.Token),
+			},
+			want: `((strings.value[indexOf(strings.name,'status')] = 'ok') AND (hasToken(strings.value[indexOf(strings.name,'message')], 'timeout'))) AND (positionCaseInsensitiveUTF8(strings.value[indexOf(strings.name,'path')], 'api') > 0)`,
+		},
+		"materialized_columns_are_exact": {
+			input: "service:checkout AND level:ERROR",
+			opts: []driverclick.ClickhouseOpt{
+				driverclick.WithMatchMode(driverclick.Substring),
+				driverclick.WithMaterializedColumns(map[string]string{"service": "service", "level": "level"}),
+				driverclick.WithFieldMatchMode("level", driverclick.ExactCaseInsensitive),
+			},
+			want: `(service = 'checkout') AND (lowerUTF8(level) = lowerUTF8('ERROR'))`,
+		},
+		"numbers_and_wildcards_are_not_affected": {
+			input: "status:200 AND path:api*",
+			opts:  []driverclick.ClickhouseOpt{driverclick.WithMatchMode(driverclick.Exact)},
+			want:  `(numbers.value[indexOf(numbers.name,'status')] = 200) AND (lowerUTF8(strings.value[indexOf(strings.name,'path')]) like lowerUTF8('api%'))`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driverclick.NewClickhouseDriver(tc.opts...).Render(e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestClickhouseTextTokens(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []driverclick.ClickhouseOpt
+		want  string
+		err   string
+	}
+
+	tokens := driverclick.WithTextStrategy(driverclick.TextTokens)
+
+	tcs := map[string]tc{
+		"like_by_default": {
+			input: "_source:timeout",
+			want:  `lowerUTF8(_source) like lowerUTF8('%timeout%')`,
+		},
+		"like_escapes_wildcards": {
+			input: `_source:"50%_off"`,
+			want:  `lowerUTF8(_source) like lowerUTF8('%50\\%\\_off%')`,
+		},
+		"token": {
+			input: "_source:timeout",
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'timeout')`,
+		},
+		"free_text_token": {
+			input: "timeout",
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'timeout')`,
+		},
+		"phrase": {
+			input: `"connection reset"`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'connection') AND hasTokenCaseInsensitive(_source, 'reset') AND lowerUTF8(_source) like lowerUTF8('%connection reset%')`,
+		},
+		"term_with_separators": {
+			input: `_source:"user_id=42%"`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'user') AND hasTokenCaseInsensitive(_source, 'id') AND hasTokenCaseInsensitive(_source, '42') AND lowerUTF8(_source) like lowerUTF8('%user\\_id=42\\%%')`,
+		},
+		"or_terms": {
+			input: `timeout OR refused OR "connection reset"`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `multiSearchAnyCaseInsensitiveUTF8(_source, ['timeout', 'refused', 'connection reset'])`,
+		},
+		"or_terms_on_the_field": {
+			input: `_source:(timeout OR refused) OR _source:503`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `(multiSearchAnyCaseInsensitiveUTF8(_source, ['timeout', 'refused'])) OR (hasTokenCaseInsensitive(_source, '503'))`,
+		},
+		"or_with_other_fields": {
+			input: `timeout OR level:error`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `(hasTokenCaseInsensitive(_source, 'timeout')) OR (lowerUTF8(strings.value[indexOf(strings.name,'level')]) like lowerUTF8('%error%'))`,
+		},
+		"and_not": {
+			input: `(timeout OR refused) AND NOT retry AND level:error`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `((multiSearchAnyCaseInsensitiveUTF8(_source, ['timeout', 'refused'])) AND (NOT(hasTokenCaseInsensitive(_source, 'retry')))) AND (lowerUTF8(strings.value[indexOf(strings.name,'level')]) like lowerUTF8('%error%'))`,
+		},
+		"wildcards_and_regexps": {
+			input: `time* AND /conn.*refused/`,
+			opts:  []driverclick.ClickhouseOpt{tokens},
+			want:  `(lowerUTF8(_source) like lowerUTF8('time%')) AND (match(lowerUTF8(_source),lowerUTF8('^(?s:conn.*refused)$')))`,
+		},
+		"text_column": {
+			input: `timeout OR refused`,
+			opts:  []driverclick.ClickhouseOpt{tokens, driverclick.WithTextColumn("body")},
+			want:  `multiSearchAnyCaseInsensitiveUTF8(body, ['timeout', 'refused'])`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driverclick.NewClickhouseDriver(tc.opts...).Render(e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestClickhouseAnalyzers(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []driverclick.ClickhouseOpt
+		want  string
+	}
+
+	analyzers := driverclick.WithAnalyzers(analysis.PerField{Default: analysis.English})
+	tokens := driverclick.WithTextStrategy(driverclick.TextTokens)
+
+	tcs := map[string]tc{
+		"phrase_is_not_stemmed": {
+			input: `message:"connections refused"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers},
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'message')]) like lowerUTF8('%connections refused%')`,
+		},
+		"stopwords_are_kept": {
+			input: `_source:"refused the upstream"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers},
+			want:  `lowerUTF8(_source) like lowerUTF8('%refused the upstream%')`,
+		},
+		"possessive_is_kept": {
+			input: `a:"it's"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers},
+			want:  `lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8('%it''s%')`,
+		},
+		"exact_match_is_not_analyzed": {
+			input: `a:Connections`,
+			opts:  []driverclick.ClickhouseOpt{analyzers, driverclick.WithMatchMode(driverclick.Exact)},
+			want:  `strings.value[indexOf(strings.name,'a')] = 'Connections'`,
+		},
+		"tokens_are_not_stemmed": {
+			input: `"Connections refused the upstream"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers, tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'connections') AND hasTokenCaseInsensitive(_source, 'refused') AND hasTokenCaseInsensitive(_source, 'the') AND hasTokenCaseInsensitive(_source, 'upstream') AND lowerUTF8(_source) like lowerUTF8('%Connections refused the upstream%')`,
+		},
+		"possessive_token": {
+			input: `_source:"it's"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers, tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'it') AND hasTokenCaseInsensitive(_source, 's') AND lowerUTF8(_source) like lowerUTF8('%it''s%')`,
+		},
+		"stopword_token": {
+			input: `_source:"the"`,
+			opts:  []driverclick.ClickhouseOpt{analyzers, tokens},
+			want:  `hasTokenCaseInsensitive(_source, 'the')`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driverclick.NewClickhouseDriver(tc.opts...).Render(e)
+			if err != nil {
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
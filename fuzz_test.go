@@ -4,7 +4,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/AlxBystrov/go-lucene/pkg/driverclick"
+	"github.com/AlxBystrov/go-lucene/pkg/driver"
 	pg_query "github.com/pganalyze/pg_query_go/v4"
 )
 
@@ -21,6 +21,8 @@ func FuzzPostgresDriver(f *testing.F) {
 		`-bbq:"woo"`,
 		`(a:b)^10`,
 		`a:foo~`,
+		`a:/b<1-20>[c-e]@/`,
+		`path:50%_off*`,
 	}
 	for _, tc := range tcs {
 		f.Add(tc)
@@ -33,7 +35,7 @@ func FuzzPostgresDriver(f *testing.F) {
 			return
 		}
 
-		f, err := driverclick.NewClickhouseDriver().Render(e)
+		f, err := driver.NewPostgresDriver().Render(e)
 		if err != nil {
 			// Ignore errors that are expected.
 			if strings.Contains(err.Error(), "unable to render operator") ||
@@ -41,7 +43,9 @@ func FuzzPostgresDriver(f *testing.F) {
 				strings.Contains(err.Error(), "literal contains null byte") ||
 				strings.Contains(err.Error(), "column name contains a double quote") ||
 				strings.Contains(err.Error(), "column name is empty") ||
-				strings.Contains(err.Error(), "the BETWEEN operator needs a two item list in the right hand side") {
+				strings.Contains(err.Error(), "the BETWEEN operator needs a two item list in the right hand side") ||
+				strings.Contains(err.Error(), "invalid regexp") ||
+				strings.Contains(err.Error(), "unable to translate the regexp") {
 				return
 			}
 
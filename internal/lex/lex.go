@@ -219,7 +219,7 @@ func lexSpace(l *Lexer) tokenStateFn {
 func lexVal(l *Lexer) tokenStateFn {
 	l.start = l.pos
 	switch r := l.next(); {
-	case isAlphaNumeric(r) || isWildcard(r) || isEscape(r):
+	case isAlphaNumeric(r) || isWildcard(r) || isEscape(r) || isTermSymbol(r):
 		l.backup()
 		return lexWord
 	case isSymbol(r):
@@ -285,7 +285,7 @@ func lexWord(l *Lexer) tokenStateFn {
 loop:
 	for {
 		switch r := l.next(); {
-		case isAlphaNumeric(r) || isWildcard(r) || r == '.' || r == '-':
+		case isAlphaNumeric(r) || isWildcard(r) || isTermSymbol(r) || r == '.' || r == '-':
 			// do nothing
 		case isEscape(r):
 			l.next() // just ignore the next character
@@ -381,6 +381,12 @@ func isWildcard(r rune) bool {
 	return r == '*' || r == '?'
 }
 
+// isTermSymbol checks whether the character is a symbol that lucene treats as part of a term. It
+// only means something to the SQL LIKE a term may be rendered to.
+func isTermSymbol(r rune) bool {
+	return r == '%'
+}
+
 // isSpace reports whether r is a space character.
 func isSpace(r rune) bool {
 	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
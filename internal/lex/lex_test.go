@@ -141,6 +141,14 @@ func TestLex(t *testing.T) {
 				tok(TLiteral, "b"),
 			},
 		},
+		"percent_in_term": {
+			in: `path:50%_off*`,
+			expected: []Token{
+				tok(TLiteral, "path"),
+				tok(TColon, ":"),
+				tok(TLiteral, "50%_off*"),
+			},
+		},
 		"regexp_tokenized": {
 			in: `/a[b]*/`,
 			expected: []Token{
@@ -0,0 +1,93 @@
+// Package tree takes apart the expressions and the documents the evaluator, the index and the
+// highlighter walk.
+package tree
+
+import (
+	"fmt"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// Expr returns the expression on a side of an expression
+func Expr(in any) (*expr.Expression, error) {
+	e, isExpr := in.(*expr.Expression)
+	if !isExpr || e == nil {
+		return nil, fmt.Errorf("expected an expression, not %T", in)
+	}
+	return e, nil
+}
+
+// Operand returns the expression NOT, MUST, MUST_NOT, BOOST and FUZZY wrap
+func Operand(e *expr.Expression) (*expr.Expression, error) {
+	sub, isExpr := e.Left.(*expr.Expression)
+	if !isExpr || sub == nil {
+		return nil, fmt.Errorf("%s must wrap an expression, not %T", e.Op, e.Left)
+	}
+	return sub, nil
+}
+
+// Operands returns both sides of AND and OR
+func Operands(e *expr.Expression) (left, right *expr.Expression, err error) {
+	left, err = Operand(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, isExpr := e.Right.(*expr.Expression)
+	if !isExpr || right == nil {
+		return nil, nil, fmt.Errorf("%s must have an expression on the right, not %T", e.Op, e.Right)
+	}
+	return left, right, nil
+}
+
+// Field returns the column name on the left side of a leaf expression
+func Field(e *expr.Expression) (string, error) {
+	left, isExpr := e.Left.(*expr.Expression)
+	if !isExpr || left == nil {
+		return "", fmt.Errorf("%s must have a field on the left, not %T", e.Op, e.Left)
+	}
+
+	switch v := left.Left.(type) {
+	case expr.Column:
+		return string(v), nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%s must have a field on the left, not %T", e.Op, left.Left)
+	}
+}
+
+// Flatten collects the scalar values of a document under dotted field names. Nested maps are
+// walked into and slices are multi valued fields.
+func Flatten(doc map[string]any) map[string][]any {
+	out := map[string][]any{}
+	flatten("", doc, out)
+	return out
+}
+
+func flatten(prefix string, doc map[string]any, out map[string][]any) {
+	for k, v := range doc {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		flattenValue(field, v, out)
+	}
+}
+
+func flattenValue(field string, v any, out map[string][]any) {
+	switch val := v.(type) {
+	case nil:
+	case map[string]any:
+		flatten(field, val, out)
+	case []any:
+		for _, item := range val {
+			flattenValue(field, item, out)
+		}
+	case []string:
+		for _, item := range val {
+			out[field] = append(out[field], item)
+		}
+	default:
+		out[field] = append(out[field], val)
+	}
+}
@@ -0,0 +1,53 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+func TestField(t *testing.T) {
+	type tc struct {
+		input *expr.Expression
+		want  string
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"column": {
+			input: expr.Eq(expr.Lit(expr.Column("a")), expr.Lit("b")),
+			want:  "a",
+		},
+		"string": {
+			input: expr.Eq(expr.Lit("a"), expr.Lit("b")),
+			want:  "a",
+		},
+		"no_field": {
+			input: expr.Lit("b"),
+			err:   "must have a field on the left",
+		},
+		"number": {
+			input: expr.Eq(expr.Lit(1), expr.Lit("b")),
+			err:   "must have a field on the left, not int",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := Field(tc.input)
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q but got [%s]", tc.err, err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error containing %q but got %q", tc.err, got)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q but got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package lucene
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene/pkg/driver"
+)
+
+func TestMySQLEndToEnd(t *testing.T) {
+	type tc struct {
+		input string
+		want  string
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"basic_equal": {
+			input: "a:b",
+			want:  "`a` = 'b'",
+		},
+		"basic_equal_with_number": {
+			input: "a:5",
+			want:  "`a` = 5",
+		},
+		"basic_greater_less_eq_with_number": {
+			input: "a:<=22 AND b:>=33",
+			want:  "(`a` <= 22) AND (`b` >= 33)",
+		},
+		"basic_wild_equal_with_*": {
+			input: "a:b*",
+			want:  "`a` LIKE 'b%' ESCAPE '!'",
+		},
+		"basic_wild_equal_with_?": {
+			input: "a:b?z",
+			want:  "`a` LIKE 'b_z' ESCAPE '!'",
+		},
+		"wild_escapes_like_symbols": {
+			input: "path:50%_off*",
+			want:  "`path` LIKE '50!%!_off%' ESCAPE '!'",
+		},
+		"wild_with_escaped_wildcards": {
+			input: `a:b\*c?`,
+			want:  "`a` LIKE 'b*c_' ESCAPE '!'",
+		},
+		"wild_with_backslash": {
+			input: `path:C\:\\tmp*`,
+			want:  "`path` LIKE 'C:\\\\tmp%' ESCAPE '!'",
+		},
+		"regexp": {
+			input: "a:/b [c]/",
+			want:  "`a` REGEXP '^(?s:b [c])$'",
+		},
+		"regexp_with_escaped_chars": {
+			input: `url:/example\.com\/foo\/.*/`,
+			want:  "`url` REGEXP '^(?s:example\\\\.com/foo/.*)$'",
+		},
+		"regexp_lucene_syntax": {
+			input: `status:/5<0-3>\d/`,
+			want:  "`status` REGEXP '^(?s:5[0-3][0-9])$'",
+		},
+		"regexp_intersection_is_an_error": {
+			input: `a:/b.*&.*c/`,
+			err:   "the intersection & at position 3 has no equivalent",
+		},
+		"inclusive_range": {
+			input: "a:[1 TO 5]",
+			want:  "`a` BETWEEN 1 AND 5",
+		},
+		"inclusive_range_over_strings": {
+			input: `a:["ab" TO "az"]`,
+			want:  "`a` BETWEEN 'ab' AND 'az'",
+		},
+		"exclusive_range": {
+			input: "a:{1 TO 5}",
+			want:  "`a` > 1 AND `a` < 5",
+		},
+		"unbounded_range": {
+			input: "a:[* TO 5.5]",
+			want:  "`a` <= 5.5",
+		},
+		"value_grouping": {
+			input: "a:(foo OR baz OR bar)",
+			want:  "`a` IN ('foo', 'baz', 'bar')",
+		},
+		"escape_quotes": {
+			input: "a:'b'",
+			want:  "`a` = '''b'''",
+		},
+		"escape_backslashes": {
+			input: `a:"b\c"`,
+			want:  "`a` = 'b\\\\c'",
+		},
+		"escaped_column_name": {
+			input: "foo\\ bar:b",
+			want:  "`foo bar` = 'b'",
+		},
+		"and_with_nesting": {
+			input: "a:foo AND b:bar",
+			want:  "(`a` = 'foo') AND (`b` = 'bar')",
+		},
+		"nested_not": {
+			input: "a:foo OR NOT b:bar",
+			want:  "(`a` = 'foo') OR (NOT(`b` = 'bar'))",
+		},
+		"boolean_query": {
+			input: "+a:b c:d -e:f",
+			want:  "(`a` = 'b') AND (NOT(`e` = 'f'))",
+		},
+		"basic_fuzzy": {
+			input: "b AND a~",
+			err:   "unable to render operator [FUZZY]",
+		},
+		"basic_boost": {
+			input: "b AND a^",
+			err:   "unable to render operator [BOOST]",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			expr, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driver.NewMySQLDriver().Render(expr)
+			if err != nil {
+				// if we got an expect error then we are fine
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, expr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package lucene
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene/pkg/driver"
+	"github.com/AlxBystrov/go-lucene/pkg/driverclick"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+type paramsRenderer interface {
+	RenderParams(e *expr.Expression) (string, []any, error)
+}
+
+func TestRenderParamsEndToEnd(t *testing.T) {
+	type tc struct {
+		input    string
+		driver   paramsRenderer
+		wantSQL  string
+		wantArgs []any
+		err      string
+	}
+
+	tcs := map[string]tc{
+		"postgres": {
+			input:    `a:b AND c:"it's" AND d:5`,
+			driver:   driver.NewPostgresDriver(),
+			wantSQL:  `(("a" = $1) AND ("c" = $2)) AND ("d" = 5)`,
+			wantArgs: []any{"b", "it's"},
+		},
+		"postgres_regexp_and_range": {
+			input:    `a:/b.*/ OR c:["x" TO "z"]`,
+			driver:   driver.NewPostgresDriver(),
+			wantSQL:  `("a" ~ $1) OR ("c" >= $2 AND "c" <= $3)`,
+			wantArgs: []any{"^(b.*)$", "x", "z"},
+		},
+		"postgres_list": {
+			input:    `a:(foo OR bar)`,
+			driver:   driver.NewPostgresDriver(),
+			wantSQL:  `"a" IN ($1, $2)`,
+			wantArgs: []any{"foo", "bar"},
+		},
+		"mysql_backslashes": {
+			input:    `a:"b\c" AND path:C\:\\tmp*`,
+			driver:   driver.NewMySQLDriver(),
+			wantSQL:  "(`a` = ?) AND (`path` LIKE ? ESCAPE '!')",
+			wantArgs: []any{`b\c`, `C:\tmp%`},
+		},
+		"sqlite_fts": {
+			input:    `timeout AND level:error`,
+			driver:   driver.NewSQLiteDriver(driver.WithFTS5(driver.FTS5{Table: "logs_fts"})),
+			wantSQL:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH ?) AND ("level" = ?)`,
+			wantArgs: []any{`"timeout"`, "error"},
+		},
+		"clickhouse": {
+			input:    `a:b AND c:"x\y"`,
+			driver:   driverclick.NewClickhouseDriver(),
+			wantSQL:  `(lowerUTF8(strings.value[indexOf(strings.name,'a')]) like lowerUTF8({p1:String})) AND (lowerUTF8(strings.value[indexOf(strings.name,'c')]) like lowerUTF8({p2:String}))`,
+			wantArgs: []any{"%b%", `%x\\y%`},
+		},
+		"clickhouse_regexp": {
+			input:    `a:/b\.c/`,
+			driver:   driverclick.NewClickhouseDriver(),
+			wantSQL:  `match(lowerUTF8(strings.value[indexOf(strings.name,'a')]),lowerUTF8({p1:String}))`,
+			wantArgs: []any{`^(?s:b\.c)$`},
+		},
+		"render_errors_are_returned": {
+			input:  `a:b AND c~`,
+			driver: driver.NewPostgresDriver(),
+			err:    "unable to render operator [FUZZY]",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			expr, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotSQL, gotArgs, err := tc.driver.RenderParams(expr)
+			if err != nil {
+				// if we got an expect error then we are fine
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, gotSQL)
+			}
+
+			if gotSQL != tc.wantSQL {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.wantSQL, gotSQL, expr)
+			}
+			if !reflect.DeepEqual(gotArgs, tc.wantArgs) {
+				t.Fatalf("\nwant args %#v\ngot       %#v\n", tc.wantArgs, gotArgs)
+			}
+		})
+	}
+}
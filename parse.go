@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/AlxBystrov/go-lucene/internal/lex"
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
 	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
 	"github.com/AlxBystrov/go-lucene/pkg/lucene/reduce"
 )
@@ -19,6 +20,24 @@ func WithDefaultField(field string) opt {
 	}
 }
 
+// WithAnalyzers analyzes the terms and phrases of the parsed expression with the analyzer of their
+// field so they match data that was indexed with the same analyzers. The analyzed expression is
+// meant for the index package, not for the SQL drivers that compare the text as it is stored.
+func WithAnalyzers(analyzers analysis.PerField) opt {
+	return func(p *parser) {
+		p.analyzers = &analyzers
+	}
+}
+
+// WithMinimumShouldMatch sets how many SHOULD clauses of the top level boolean query a document has
+// to match, the same as the minimum_should_match of an elastic query_string query. Clauses next to
+// each other without a + or - prefix become SHOULD clauses instead of an implicit AND when it is set.
+func WithMinimumShouldMatch(n int) opt {
+	return func(p *parser) {
+		p.minShouldMatch = n
+	}
+}
+
 // Parse will parse using a buffer and the shift reduce algorithm. It scales rather well since
 // it is a one pass algorithm with no backtracking.
 func Parse(input string, opts ...opt) (e *expr.Expression, err error) {
@@ -42,14 +61,20 @@ func Parse(input string, opts ...opt) (e *expr.Expression, err error) {
 		return e, err
 	}
 
+	if p.analyzers != nil {
+		ex = p.analyzers.Analyze(ex)
+	}
+
 	return ex, nil
 }
 
 type parser struct {
-	lex          *lex.Lexer
-	stack        []any
-	nonTerminals []lex.Token
-	defaultField string
+	lex            *lex.Lexer
+	stack          []any
+	nonTerminals   []lex.Token
+	defaultField   string
+	analyzers      *analysis.PerField
+	minShouldMatch int
 }
 
 func (p *parser) parse() (e *expr.Expression, err error) {
@@ -68,6 +93,8 @@ func (p *parser) parse() (e *expr.Expression, err error) {
 				)
 			}
 
+			final = reduce.Close(final, p.minShouldMatch)
+
 			if final.Op == expr.Literal && p.defaultField != "" {
 				final = expr.Expr(p.defaultField, expr.Equals, final.Left)
 			}
@@ -78,6 +105,16 @@ func (p *parser) parse() (e *expr.Expression, err error) {
 			return final, nil
 		}
 
+		// a clause that starts with a prefix or a paren right after another clause is in an
+		// implicit AND with it just like a term is
+		if startsClause(next) && p.afterClause(next) {
+			err = p.injectImplicitAnd()
+			if err != nil {
+				return e, err
+			}
+			continue
+		}
+
 		if p.shouldShift(next) {
 			tok := p.shift()
 			if lex.IsTerminal(tok) {
@@ -89,23 +126,10 @@ func (p *parser) parse() (e *expr.Expression, err error) {
 
 				// we should always check if the current top of the stack is another token
 				// if it isn't then we have an implicit AND we need to inject.
-				if len(p.stack) > 0 {
-					_, isTopToken := p.stack[len(p.stack)-1].(lex.Token)
-					if !isTopToken {
-						implAnd := lex.Token{Typ: lex.TAnd, Val: "AND"}
-						// act as if we just saw an AND and check if we need to reduce the
-						// current token stack first.
-						if !p.shouldShift(implAnd) {
-							err = p.reduce()
-							if err != nil {
-								return e, err
-							}
-						}
-
-						// if we have a literal as the previous parsed thing then
-						// we must be in an implicit AND and should reduce
-						p.stack = append(p.stack, implAnd)
-						p.nonTerminals = append(p.nonTerminals, implAnd)
+				if p.afterClause(tok) {
+					err = p.injectImplicitAnd()
+					if err != nil {
+						return e, err
 					}
 				}
 
@@ -125,6 +149,44 @@ func (p *parser) parse() (e *expr.Expression, err error) {
 	}
 }
 
+// afterClause checks whether the top of the stack ends a clause so the next token starts a new one.
+// That is a parsed expression, the closing bracket of a group that still has to be reduced or a
+// fuzzy or boost without a value. A term after a ~ or ^ is their value instead.
+func (p *parser) afterClause(next lex.Token) bool {
+	if len(p.stack) == 0 {
+		return false
+	}
+	top, isTopToken := p.stack[len(p.stack)-1].(lex.Token)
+	if !isTopToken || anyClosingBracket(top) {
+		return true
+	}
+	return !lex.IsTerminal(next) && (top.Typ == lex.TTilde || top.Typ == lex.TCarrot)
+}
+
+// injectImplicitAnd pushes the AND between two clauses that are next to each other
+func (p *parser) injectImplicitAnd() error {
+	// act as if we just saw an AND and check if we need to reduce the
+	// current token stack first.
+	for !p.shouldShift(reduce.ImplicitAnd) {
+		err := p.reduce()
+		if err != nil {
+			return err
+		}
+	}
+
+	p.stack = append(p.stack, reduce.ImplicitAnd)
+	p.nonTerminals = append(p.nonTerminals, reduce.ImplicitAnd)
+	return nil
+}
+
+// startsClause checks whether the token is a prefix or an open paren that starts a new clause
+func startsClause(tok lex.Token) bool {
+	return tok.Typ == lex.TPlus ||
+		tok.Typ == lex.TMinus ||
+		tok.Typ == lex.TNot ||
+		tok.Typ == lex.TLParen
+}
+
 func (p *parser) shift() (tok lex.Token) {
 	return p.lex.Next()
 }
@@ -3,8 +3,10 @@ package lucene
 import (
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
 	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
 )
 
@@ -207,6 +209,45 @@ func TestParseLucene(t *testing.T) {
 				),
 			),
 		},
+		"boolean_query": {
+			input: "+a:b c:d -e:f",
+			want: expr.BOOL([]*expr.BooleanClause{
+				expr.Clause(expr.OccurMust, expr.Eq("a", "b")),
+				expr.Clause(expr.OccurShould, expr.Eq("c", "d")),
+				expr.Clause(expr.OccurMustNot, expr.Eq("e", "f")),
+			}),
+		},
+		"boolean_query_prefix_after_term": {
+			input: "a:b -c:d",
+			want: expr.BOOL([]*expr.BooleanClause{
+				expr.Clause(expr.OccurShould, expr.Eq("a", "b")),
+				expr.Clause(expr.OccurMustNot, expr.Eq("c", "d")),
+			}),
+		},
+		"boolean_query_keeps_groups": {
+			input: "(+a:b c:d) (+e:f g:h)",
+			want: expr.AND(
+				expr.BOOL([]*expr.BooleanClause{
+					expr.Clause(expr.OccurMust, expr.Eq("a", "b")),
+					expr.Clause(expr.OccurShould, expr.Eq("c", "d")),
+				}),
+				expr.BOOL([]*expr.BooleanClause{
+					expr.Clause(expr.OccurMust, expr.Eq("e", "f")),
+					expr.Clause(expr.OccurShould, expr.Eq("g", "h")),
+				}),
+			),
+		},
+		"boolean_query_with_grouped_clause": {
+			input: "+(a:b OR c:d) -e:f^2",
+			want: expr.BOOL([]*expr.BooleanClause{
+				expr.Clause(expr.OccurMust, expr.OR(expr.Eq("a", "b"), expr.Eq("c", "d"))),
+				expr.Clause(expr.OccurMustNot, expr.BOOST(expr.Eq("e", "f"), 2.0)),
+			}),
+		},
+		"implicit_and_after_group": {
+			input: "(a:b) c:d",
+			want:  expr.AND(expr.Eq("a", "b"), expr.Eq("c", "d")),
+		},
 		"basic_escaping": {
 			input: `a:\(1\+1\)\:2`,
 			want:  expr.Eq("a", expr.Lit(`(1+1):2`)),
@@ -539,6 +580,102 @@ func TestParseLucene(t *testing.T) {
 	}
 }
 
+func TestParseWithAnalyzers(t *testing.T) {
+	type tc struct {
+		input string
+		want  *expr.Expression
+	}
+
+	analyzers := analysis.PerField{
+		Fields: map[string]*analysis.Analyzer{"message": analysis.English},
+	}
+
+	tcs := map[string]tc{
+		"term": {
+			input: "message:Connections",
+			want:  expr.Eq("message", "connect"),
+		},
+		"phrase": {
+			input: `message:"Refused the Connection"`,
+			want:  expr.Eq("message", "refus connect"),
+		},
+		"unanalyzed_field": {
+			input: "level:ERROR",
+			want:  expr.Eq("level", "ERROR"),
+		},
+		"default_field": {
+			input: "Running AND level:ERROR",
+			want:  expr.AND(expr.Eq("message", "run"), expr.Eq("level", "ERROR")),
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.input, WithDefaultField("message"), WithAnalyzers(analyzers))
+			if err != nil {
+				t.Fatalf("expected no error during parsing but got [%s]", err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf(errTemplate, "analyzed expressions don't match", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseWithMinimumShouldMatch(t *testing.T) {
+	type tc struct {
+		input string
+		want  *expr.Expression
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"implicit_clauses_are_should": {
+			input: "a:b c:d e:f",
+			want: expr.BOOL([]*expr.BooleanClause{
+				expr.Clause(expr.OccurShould, expr.Eq("a", "b")),
+				expr.Clause(expr.OccurShould, expr.Eq("c", "d")),
+				expr.Clause(expr.OccurShould, expr.Eq("e", "f")),
+			}, 2),
+		},
+		"with_prefixes": {
+			input: "+a:b c:d e:f -g:h",
+			want: expr.BOOL([]*expr.BooleanClause{
+				expr.Clause(expr.OccurMust, expr.Eq("a", "b")),
+				expr.Clause(expr.OccurShould, expr.Eq("c", "d")),
+				expr.Clause(expr.OccurShould, expr.Eq("e", "f")),
+				expr.Clause(expr.OccurMustNot, expr.Eq("g", "h")),
+			}, 2),
+		},
+		"nested_groups_are_not_affected": {
+			input: "(a:b c:d) OR e:f",
+			want:  expr.OR(expr.AND(expr.Eq("a", "b"), expr.Eq("c", "d")), expr.Eq("e", "f")),
+		},
+		"too_few_should_clauses": {
+			input: "+a:b c:d",
+			err:   "minimum_should_match 2 is more than the 1 SHOULD clauses",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.input, WithMinimumShouldMatch(2))
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("expected no error during parsing but got [%s]", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf(errTemplate, "parsed expressions don't match", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestParseFailure(t *testing.T) {
 	type tc struct {
 		input string
@@ -0,0 +1,180 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+func TestAnalyzers(t *testing.T) {
+	type tc struct {
+		analyzer *Analyzer
+		input    string
+		want     []Token
+	}
+
+	tcs := map[string]tc{
+		"standard": {
+			analyzer: Standard,
+			input:    "GET /api/v1 Refused",
+			want: []Token{
+				{Term: "get", Start: 0, End: 3, Position: 0},
+				{Term: "api", Start: 5, End: 8, Position: 1},
+				{Term: "v1", Start: 9, End: 11, Position: 2},
+				{Term: "refused", Start: 12, End: 19, Position: 3},
+			},
+		},
+		"english_keeps_positions_of_stopwords": {
+			analyzer: English,
+			input:    "The connections to Café",
+			want: []Token{
+				{Term: "connect", Start: 4, End: 15, Position: 1},
+				{Term: "cafe", Start: 19, End: 24, Position: 3},
+			},
+		},
+		"keyword": {
+			analyzer: Keyword,
+			input:    "Hello World",
+			want:     []Token{{Term: "Hello World", Start: 0, End: 11}},
+		},
+		"whitespace": {
+			analyzer: &Analyzer{Tokenizer: WhitespaceTokenizer},
+			input:    " a-b  c ",
+			want: []Token{
+				{Term: "a-b", Start: 1, End: 4, Position: 0},
+				{Term: "c", Start: 6, End: 7, Position: 1},
+			},
+		},
+		"folding": {
+			analyzer: &Analyzer{Filters: []Filter{Lowercase, ASCIIFolding}},
+			input:    "Straße Ærø",
+			want: []Token{
+				{Term: "strasse", Start: 0, End: 7, Position: 0},
+				{Term: "aero", Start: 8, End: 13, Position: 1},
+			},
+		},
+		"empty": {
+			analyzer: English,
+			input:    "",
+			want:     []Token{},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got := tc.analyzer.Analyze(tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %+v\ngot  %+v\n", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	// vectors from the reference implementation
+	tcs := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"digitizer":      "digit",
+		"generalization": "gener",
+		"hopefulness":    "hope",
+		"electrical":     "electr",
+		"adjustment":     "adjust",
+		"adoption":       "adopt",
+		"controlling":    "control",
+		"rate":           "rate",
+		"connections":    "connect",
+		"running":        "run",
+		"as":             "as",
+		"café":           "café",
+	}
+
+	for word, want := range tcs {
+		t.Run(word, func(t *testing.T) {
+			if got := Stem(word); got != want {
+				t.Fatalf("Stem(%q) = %q, want %q", word, got, want)
+			}
+		})
+	}
+}
+
+func TestPerFieldAnalyze(t *testing.T) {
+	analyzers := PerField{
+		Default: Standard,
+		Fields: map[string]*Analyzer{
+			"message": English,
+			"id":      nil,
+		},
+	}
+
+	type tc struct {
+		input *expr.Expression
+		want  *expr.Expression
+	}
+
+	tcs := map[string]tc{
+		"term": {
+			input: expr.Eq("message", "Running"),
+			want:  expr.Eq("message", "run"),
+		},
+		"phrase": {
+			input: expr.Eq("message", "The Quick Connections"),
+			want:  expr.Eq("message", "quick connect"),
+		},
+		"default_analyzer": {
+			input: expr.Eq("level", "ERROR"),
+			want:  expr.Eq("level", "error"),
+		},
+		"unanalyzed_field": {
+			input: expr.Eq("id", "AbC"),
+			want:  expr.Eq("id", "AbC"),
+		},
+		"bare_term": {
+			input: expr.Lit("Hello"),
+			want:  expr.Lit("hello"),
+		},
+		"only_stopwords": {
+			input: expr.Eq("message", "the"),
+			want:  expr.Eq("message", "the"),
+		},
+		"numbers": {
+			input: expr.Eq("message", 5),
+			want:  expr.Eq("message", 5),
+		},
+		"wildcards_are_not_analyzed": {
+			input: expr.LIKE("message", expr.WILD("Runn*")),
+			want:  expr.LIKE("message", expr.WILD("Runn*")),
+		},
+		"in": {
+			input: expr.IN("message", expr.LIST(expr.Lit("Runs"), expr.Lit("Walking"))),
+			want:  expr.IN("message", expr.LIST(expr.Lit("run"), expr.Lit("walk"))),
+		},
+		"nested": {
+			input: expr.AND(expr.BOOST(expr.Eq("message", "Runs"), 2), expr.NOT(expr.Eq("level", "WARN"))),
+			want:  expr.AND(expr.BOOST(expr.Eq("message", "run"), 2), expr.NOT(expr.Eq("level", "warn"))),
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got := analyzers.Analyze(tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %#v\ngot  %#v\n", tc.want, got)
+			}
+		})
+	}
+}
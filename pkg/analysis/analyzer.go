@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a term produced by analyzing text
+type Token struct {
+	Term string
+	// Start and End are the byte offsets of the token in the original text
+	Start int
+	End   int
+	// Position is the position of the token in the token stream. Removed tokens (e.g. stopwords)
+	// leave a gap so phrases don't match across them.
+	Position int
+}
+
+// Tokenizer splits text into tokens
+type Tokenizer func(text string) []Token
+
+// Filter transforms a token stream. Filters may drop tokens but must keep the positions of the
+// remaining ones.
+type Filter func(tokens []Token) []Token
+
+// Analyzer is a tokenizer followed by a chain of filters, the same as a lucene analyzer.
+type Analyzer struct {
+	Tokenizer Tokenizer
+	Filters   []Filter
+}
+
+// predefined analyzers
+var (
+	// Standard splits on anything that isn't a letter or a digit and lower cases the tokens
+	Standard = &Analyzer{Tokenizer: StandardTokenizer, Filters: []Filter{Lowercase}}
+	// English is Standard with ASCII folding, english stopwords and porter stemming
+	English = &Analyzer{
+		Tokenizer: StandardTokenizer,
+		Filters:   []Filter{Lowercase, ASCIIFolding, Stopwords(EnglishStopwords...), PorterStem},
+	}
+	// Keyword keeps the whole text as a single token
+	Keyword = &Analyzer{Tokenizer: KeywordTokenizer}
+)
+
+// Analyze runs the text through the tokenizer and the filters.
+func (a *Analyzer) Analyze(text string) []Token {
+	tokenize := a.Tokenizer
+	if tokenize == nil {
+		tokenize = StandardTokenizer
+	}
+
+	tokens := tokenize(text)
+	for _, f := range a.Filters {
+		tokens = f(tokens)
+	}
+	return tokens
+}
+
+// Terms returns the terms of the analyzed text
+func (a *Analyzer) Terms(text string) []string {
+	tokens := a.Analyze(text)
+	out := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, t.Term)
+	}
+	return out
+}
+
+// StandardTokenizer splits text on anything that isn't a letter or a digit
+func StandardTokenizer(text string) []Token {
+	out := []Token{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			out = append(out, Token{Term: text[start:i], Start: start, End: i, Position: len(out)})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, Token{Term: text[start:], Start: start, End: len(text), Position: len(out)})
+	}
+	return out
+}
+
+// WhitespaceTokenizer splits text on whitespace
+func WhitespaceTokenizer(text string) []Token {
+	out := []Token{}
+	start := -1
+	for i, r := range text {
+		switch {
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		case unicode.IsSpace(r) && start >= 0:
+			out = append(out, Token{Term: text[start:i], Start: start, End: i, Position: len(out)})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, Token{Term: text[start:], Start: start, End: len(text), Position: len(out)})
+	}
+	return out
+}
+
+// KeywordTokenizer returns the whole text as a single token
+func KeywordTokenizer(text string) []Token {
+	if text == "" {
+		return []Token{}
+	}
+	return []Token{{Term: text, Start: 0, End: len(text)}}
+}
+
+// PerField picks the analyzer of a field. Fields without their own analyzer use the default one.
+// A nil analyzer leaves the field unanalyzed.
+type PerField struct {
+	Default *Analyzer
+	Fields  map[string]*Analyzer
+}
+
+// For returns the analyzer of the field or nil if the field isn't analyzed.
+func (p PerField) For(field string) *Analyzer {
+	if a, found := p.Fields[field]; found {
+		return a
+	}
+	return p.Default
+}
+
+// join renders analyzed terms back into a single literal
+func join(terms []string) string {
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"strings"
+)
+
+// EnglishStopwords are the default stopwords of the lucene english analyzer
+var EnglishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in", "into", "is", "it",
+	"no", "not", "of", "on", "or", "such", "that", "the", "their", "then", "there", "these", "they",
+	"this", "to", "was", "will", "with",
+}
+
+// Lowercase lower cases every token
+func Lowercase(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Term = strings.ToLower(tokens[i].Term)
+	}
+	return tokens
+}
+
+// ASCIIFolding replaces accented latin letters and ligatures with their ASCII equivalent
+// (e.g. café becomes cafe and straße becomes strasse).
+func ASCIIFolding(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Term = fold(tokens[i].Term)
+	}
+	return tokens
+}
+
+// Stopwords drops the tokens that are one of the words. The words are compared as is so the filter
+// usually comes after Lowercase.
+func Stopwords(words ...string) Filter {
+	stop := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		stop[w] = struct{}{}
+	}
+	return func(tokens []Token) []Token {
+		out := tokens[:0]
+		for _, t := range tokens {
+			if _, found := stop[t.Term]; !found {
+				out = append(out, t)
+			}
+		}
+		return out
+	}
+}
+
+// PorterStem reduces english words to their stem with the porter algorithm
+// (e.g. connections becomes connect). It expects lower cased tokens.
+func PorterStem(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Term = Stem(tokens[i].Term)
+	}
+	return tokens
+}
+
+// folding maps accented latin letters to ASCII. The latin-1 supplement and latin extended-A
+// blocks cover the languages we see in practice.
+var folding = map[rune]string{}
+
+func init() {
+	groups := map[string]string{
+		"ÀÁÂÃÄÅĀĂĄ": "A", "àáâãäåāăą": "a",
+		"ÇĆĈĊČ": "C", "çćĉċč": "c",
+		"ÐĎĐ": "D", "ðďđ": "d",
+		"ÈÉÊËĒĔĖĘĚ": "E", "èéêëēĕėęě": "e",
+		"ĜĞĠĢ": "G", "ĝğġģ": "g",
+		"ĤĦ": "H", "ĥħ": "h",
+		"ÌÍÎÏĨĪĬĮİ": "I", "ìíîïĩīĭįı": "i",
+		"Ĵ": "J", "ĵ": "j",
+		"Ķ": "K", "ķĸ": "k",
+		"ĹĻĽĿŁ": "L", "ĺļľŀł": "l",
+		"ÑŃŅŇŊ": "N", "ñńņňŉŋ": "n",
+		"ÒÓÔÕÖØŌŎŐ": "O", "òóôõöøōŏő": "o",
+		"ŔŖŘ": "R", "ŕŗř": "r",
+		"ŚŜŞŠ": "S", "śŝşšſ": "s",
+		"ŢŤŦ": "T", "ţťŧ": "t",
+		"ÙÚÛÜŨŪŬŮŰŲ": "U", "ùúûüũūŭůűų": "u",
+		"Ŵ": "W", "ŵ": "w",
+		"ÝŶŸ": "Y", "ýÿŷ": "y",
+		"ŹŻŽ": "Z", "źżž": "z",
+		"Æ": "AE", "æ": "ae", "Œ": "OE", "œ": "oe", "Ĳ": "IJ", "ĳ": "ij",
+		"Þ": "TH", "þ": "th", "ß": "ss",
+	}
+	for letters, ascii := range groups {
+		for _, r := range letters {
+			folding[r] = ascii
+		}
+	}
+}
+
+func fold(s string) string {
+	needsFolding := false
+	for _, r := range s {
+		if _, found := folding[r]; found {
+			needsFolding = true
+			break
+		}
+	}
+	if !needsFolding {
+		return s
+	}
+
+	var sb strings.Builder
+	for _, r := range s {
+		if ascii, found := folding[r]; found {
+			sb.WriteString(ascii)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
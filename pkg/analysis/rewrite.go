@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// Analyze returns a copy of the expression with the string terms and phrases analyzed by the
+// analyzer of their field. A phrase becomes its analyzed terms joined by a single space. Bare terms
+// without a field use the default analyzer.
+//
+// Just like in lucene wildcards, regexps, fuzzy terms and ranges are not analyzed. Terms that
+// analyze to nothing (e.g. only stopwords) and terms on fields without an analyzer are kept as is.
+//
+// The analyzed terms only match data indexed with the same analyzers, like the index package
+// does. They must not be rendered into LIKE or = filters on columns that hold the text as it is.
+func (p PerField) Analyze(e *expr.Expression) *expr.Expression {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case expr.Literal:
+		return p.term("", e)
+	case expr.Equals:
+		return p.leaf(e, func(field string, right *expr.Expression) any {
+			return p.term(field, right)
+		})
+	case expr.In:
+		return p.leaf(e, func(field string, right *expr.Expression) any {
+			vals, isList := right.Left.([]*expr.Expression)
+			if right.Op != expr.List || !isList {
+				return right
+			}
+			out := make([]*expr.Expression, 0, len(vals))
+			for _, v := range vals {
+				out = append(out, p.term(field, v))
+			}
+			list := *right
+			list.Left = out
+			return &list
+		})
+	case expr.And, expr.Or, expr.Not, expr.Must, expr.MustNot, expr.Boost:
+		// copy the expression so the boost power is kept
+		out := *e
+		if left, isExpr := e.Left.(*expr.Expression); isExpr {
+			out.Left = p.Analyze(left)
+		}
+		if right, isExpr := e.Right.(*expr.Expression); isExpr {
+			out.Right = p.Analyze(right)
+		}
+		return &out
+	case expr.Bool:
+		// copy the expression so the minimum_should_match is kept
+		out := *e
+		clauses := []*expr.BooleanClause{}
+		for _, c := range e.Clauses() {
+			clauses = append(clauses, &expr.BooleanClause{Occur: c.Occur, Expr: p.Analyze(c.Expr)})
+		}
+		out.Left = clauses
+		return &out
+	default:
+		return e
+	}
+}
+
+// leaf rewrites the right side of a leaf on a column.
+func (p PerField) leaf(e *expr.Expression, rewrite func(field string, right *expr.Expression) any) *expr.Expression {
+	left, isExpr := e.Left.(*expr.Expression)
+	right, isRight := e.Right.(*expr.Expression)
+	if !isExpr || left == nil || !isRight || right == nil {
+		return e
+	}
+
+	var field string
+	switch v := left.Left.(type) {
+	case expr.Column:
+		field = string(v)
+	case string:
+		field = v
+	default:
+		return e
+	}
+
+	out := *e
+	out.Right = rewrite(field, right)
+	return &out
+}
+
+// term analyzes a string literal with the analyzer of the field.
+func (p PerField) term(field string, e *expr.Expression) *expr.Expression {
+	s, isStr := e.Left.(string)
+	if e.Op != expr.Literal || !isStr {
+		return e
+	}
+
+	a := p.For(field)
+	if a == nil {
+		return e
+	}
+	terms := a.Terms(s)
+	if len(terms) == 0 {
+		return e
+	}
+	return expr.Lit(join(terms))
+}
@@ -0,0 +1,270 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/params"
+)
+
+// Shared is the shared set of render functions that can be used as a base and overriden
+// for each flavor of sql
+var Shared = map[expr.Operator]RenderFN{
+	expr.Literal: literal,
+	expr.And:     basicCompound(expr.And),
+	expr.Or:      basicCompound(expr.Or),
+	expr.Not:     basicWrap(expr.Not),
+	expr.Equals:  equals,
+	expr.Range:   rang,
+	expr.Must:    noop,                // must doesn't really translate to sql
+	expr.MustNot: basicWrap(expr.Not), // must not is really just a negation
+	// expr.Fuzzy:     unsupported,
+	// expr.Boost:     unsupported,
+	expr.Wild:      literal,
+	expr.Regexp:    literal,
+	expr.Greater:   greater,
+	expr.GreaterEq: greaterEq,
+	expr.Less:      less,
+	expr.LessEq:    lessEq,
+	expr.In:        inFn,
+	expr.List:      list,
+}
+
+// SharedValues is the shared set of value rendering functions, the drivers that don't set their
+// own use them
+var SharedValues = map[expr.Operator]ValueFN{
+	expr.Like: like,
+}
+
+// Base is the base driver that is embedded in each driver
+type Base struct {
+	RenderFNs map[expr.Operator]RenderFN
+
+	// QuoteColumn and QuoteString, if set, serialize the column names and the string values for
+	// dialects that don't quote them like postgres does
+	QuoteColumn func(name string) (string, error)
+	QuoteString func(s string) string
+
+	// ValueFNs render the operators that need the value on the right side as it is instead of
+	// serialized, like the patterns of LIKE, when the RenderFNs don't have them. SharedValues are
+	// used when it isn't set.
+	ValueFNs map[expr.Operator]ValueFN
+
+	// Intercept, if set, is called with every expression that is used as a filter before it is
+	// rendered. It renders the expressions it handles itself and leaves the others to the
+	// render functions.
+	Intercept func(e *expr.Expression, v Values) (s string, handled bool, err error)
+
+	// Params is the bind parameter RenderParams writes, the postgres $1 by default
+	Params params.Placeholder
+
+	// args collects the arguments of RenderParams
+	args *[]any
+}
+
+// Values writes the string values into the filter, as bind parameters when the expression is
+// rendered with RenderParams and as quoted literals otherwise
+type Values struct {
+	quote       func(s string) string
+	placeholder params.Placeholder
+	args        *[]any
+}
+
+// String writes a string value
+func (v Values) String(s string) string {
+	if v.args == nil {
+		return v.quote(s)
+	}
+	*v.args = append(*v.args, s)
+	return v.placeholder(len(*v.args))
+}
+
+// valueFN returns the value rendering function of an operator the render functions don't have
+func (b Base) valueFN(op expr.Operator) (ValueFN, bool) {
+	if _, ok := b.RenderFNs[op]; ok {
+		return nil, false
+	}
+	fns := b.ValueFNs
+	if fns == nil {
+		fns = SharedValues
+	}
+	fn, ok := fns[op]
+	return fn, ok
+}
+
+// values returns the writer of the string values of the driver
+func (b Base) values() Values {
+	v := Values{quote: b.QuoteString, placeholder: b.Params, args: b.args}
+	if v.quote == nil {
+		v.quote = quote
+	}
+	if v.placeholder == nil {
+		v.placeholder = params.Numbered
+	}
+	return v
+}
+
+// Render will render the expression based on the renderFNs provided by the driver.
+func (b Base) Render(e *expr.Expression) (s string, err error) {
+	if e == nil {
+		return "", nil
+	}
+
+	// sql has no optional clauses so boolean queries are rendered as the filter they are equivalent to
+	if e.Op == expr.Bool {
+		return b.Render(expr.ToFilter(e))
+	}
+
+	if b.Intercept != nil {
+		s, handled, err := b.Intercept(e, b.values())
+		if err != nil || handled {
+			return s, err
+		}
+	}
+
+	// only the sides of the boolean operators are filters, the sides of everything else are values
+	// that are never intercepted
+	operands := b
+	if !isConnective(e.Op) {
+		operands.Intercept = nil
+	}
+
+	left, err := operands.serialize(e.Left)
+	if err != nil {
+		return s, err
+	}
+
+	if valueFN, ok := b.valueFN(e.Op); ok {
+		right, isExpr := e.Right.(*expr.Expression)
+		if !isExpr || right == nil {
+			return s, fmt.Errorf("unable to render operator [%s] without a value", e.Op)
+		}
+		if !b.isSimple(e.Left) {
+			left = "(" + left + ")"
+		}
+		return valueFN(left, right, b.values())
+	}
+
+	right, err := operands.serialize(e.Right)
+	if err != nil {
+		return s, err
+	}
+
+	if e.Op != expr.Range && e.Op != expr.Not && e.Op != expr.List && e.Op != expr.In && e.Op != expr.Literal && e.Op != expr.Must && e.Op != expr.MustNot {
+		if !b.isSimple(e.Left) {
+			left = "(" + left + ")"
+		}
+		if !b.isSimple(e.Right) {
+			right = "(" + right + ")"
+		}
+	}
+
+	fn, ok := b.RenderFNs[e.Op]
+	if !ok {
+		return s, fmt.Errorf("unable to render operator [%s]", e.Op)
+	}
+
+	return fn(left, right)
+}
+
+// RenderParams renders the expression like Render but with bind parameters in place of the string
+// values, which are returned as the arguments of the query. The columns and the numbers are written
+// as they are.
+func (b Base) RenderParams(e *expr.Expression) (s string, args []any, err error) {
+	b.args = &[]any{}
+	s, err = b.Render(e)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, *b.args, nil
+}
+
+func isConnective(op expr.Operator) bool {
+	return op == expr.And || op == expr.Or || op == expr.Not || op == expr.Must || op == expr.MustNot
+}
+
+func (b Base) isSimple(in any) bool {
+	switch v := in.(type) {
+	case *expr.Expression:
+		return v.Op == expr.Undefined || v.Op == expr.Literal || v.Op == expr.Regexp || v.Op == expr.Wild
+	case expr.Column:
+		return true
+	case nil:
+		return true
+	case string, int, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b Base) serialize(in any) (s string, err error) {
+	if in == nil {
+		return "", nil
+	}
+
+	switch v := in.(type) {
+	case *expr.Expression:
+		return b.Render(v)
+	case []*expr.Expression:
+		strs := []string{}
+		for _, e := range v {
+			s, err = b.Render(e)
+			if err != nil {
+				return s, err
+			}
+			strs = append(strs, s)
+		}
+		return strings.Join(strs, ", "), nil
+	case *expr.RangeBoundary:
+		min, err := b.bound(v.Min)
+		if err != nil {
+			return "", err
+		}
+		max, err := b.bound(v.Max)
+		if err != nil {
+			return "", err
+		}
+
+		if v.Inclusive {
+			return fmt.Sprintf("[%s, %s]", min, max), nil
+		}
+		return fmt.Sprintf("(%s, %s)", min, max), nil
+
+	case expr.Column:
+		if len(v) == 0 {
+			return "", fmt.Errorf("column name is empty")
+		}
+		if b.QuoteColumn != nil {
+			return b.QuoteColumn(string(v))
+		}
+		if strings.ContainsRune(string(v), '"') {
+			return "", fmt.Errorf("column name contains a double quote: %q", v)
+		}
+		// Always escape column names with double quotes,
+		// otherwise we need to know the reserved words
+		// which might change in the future.
+		return fmt.Sprintf(`"%s"`, string(v)), nil
+	case string:
+		if err := validString(v); err != nil {
+			return "", err
+		}
+		return b.values().String(v), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// bound serializes the bound of a range. The * of an unbounded side is always a literal so the
+// render functions can tell it apart.
+func (b Base) bound(in any) (string, error) {
+	raw := in
+	if e, isExpr := in.(*expr.Expression); isExpr && e != nil && (e.Op == expr.Literal || e.Op == expr.Wild) {
+		raw = e.Left
+	}
+	if raw == "*" {
+		return quote("*"), nil
+	}
+	return b.serialize(in)
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/params"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// mysqlLikeEscape is the escape character of the LIKE patterns. It isn't the default backslash so
+// patterns don't need a second round of backslash escaping inside the string literal.
+const mysqlLikeEscape = '!'
+
+// MySQLDriver transforms a parsed lucene expression to a MySQL (or MariaDB) sql filter. It
+// assumes the default sql mode where backslashes escape characters in string literals.
+type MySQLDriver struct {
+	Base
+}
+
+// NewMySQLDriver creates a new driver that will output a parsed lucene expression as a MySQL filter.
+func NewMySQLDriver() MySQLDriver {
+	fns := map[expr.Operator]RenderFN{
+		expr.Literal: literal,
+		expr.Range:   mysqlRange,
+	}
+
+	for op, sharedFN := range Shared {
+		_, found := fns[op]
+		if !found {
+			fns[op] = sharedFN
+		}
+	}
+
+	return MySQLDriver{
+		Base{
+			RenderFNs:   fns,
+			ValueFNs:    map[expr.Operator]ValueFN{expr.Like: mysqlLike},
+			QuoteColumn: mysqlColumn,
+			QuoteString: mysqlString,
+			Params:      params.Question,
+		},
+	}
+}
+
+// mysqlColumn quotes a column name with backticks, backticks in the name are doubled
+func mysqlColumn(name string) (string, error) {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`", nil
+}
+
+var mysqlEscaper = strings.NewReplacer(`\`, `\\`, "'", "''")
+
+// mysqlString quotes a string value. Backslashes are escaped since MySQL treats them as escape
+// characters in string literals.
+func mysqlString(s string) string {
+	return "'" + mysqlEscaper.Replace(s) + "'"
+}
+
+// mysqlLike renders regexps with REGEXP and wildcards with LIKE. The regexps are translated to the
+// RE2 syntax which the ICU (MySQL 8) and PCRE (MariaDB) regexps share.
+func mysqlLike(left string, right *expr.Expression, v Values) (string, error) {
+	pattern, err := patternOf(right)
+	if err != nil {
+		return "", err
+	}
+	if right.Op == expr.Regexp {
+		translated, err := regex.ToRE2(pattern)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s REGEXP %s", left, v.String(translated)), nil
+	}
+
+	return fmt.Sprintf(
+		"%s LIKE %s ESCAPE '%c'",
+		left,
+		v.String(wildcard.Parse(pattern).Like(mysqlLikeEscape)),
+		mysqlLikeEscape,
+	), nil
+}
+
+// mysqlRange renders inclusive ranges with BETWEEN and everything else like the shared ranges
+func mysqlRange(left, right string) (string, error) {
+	rawMin, rawMax, inclusive, err := rangeBounds(right)
+	if err != nil {
+		return "", err
+	}
+	if !inclusive || rawMin == "'*'" || rawMax == "'*'" {
+		return rang(left, right)
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", left, number(rawMin), number(rawMax)), nil
+}
@@ -0,0 +1,195 @@
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// RenderFN is a rendering function. It takes the left and right side of the operator serialized to a string
+// and serializes the entire expression
+type RenderFN func(left, right string) (string, error)
+
+// ValueFN is a rendering function that takes the left side serialized to a string and the right
+// side as it is. The string values it writes go through v so they are bound as parameters when the
+// expression is rendered with RenderParams.
+type ValueFN func(left string, right *expr.Expression, v Values) (string, error)
+
+func literal(left, right string) (string, error) {
+	return left, validString(left)
+}
+
+// validString rejects the strings that can't be written into a filter
+func validString(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("literal contains invalid utf8: %q", s)
+	}
+	if strings.ContainsRune(s, 0) {
+		return fmt.Errorf("literal contains null byte: %q", s)
+	}
+	return nil
+}
+
+func equals(left, right string) (string, error) {
+	return fmt.Sprintf("%s = %s", left, right), nil
+}
+
+func noop(left, right string) (string, error) {
+	return left, nil
+}
+
+// like renders regexps with the POSIX ~ operator and wildcards with SIMILAR TO. Both are
+// translated from the lucene syntax so they match the whole value and every character that isn't
+// a lucene operator matches itself.
+func like(left string, right *expr.Expression, v Values) (string, error) {
+	pattern, err := patternOf(right)
+	if err != nil {
+		return "", err
+	}
+	if right.Op == expr.Regexp {
+		translated, err := regex.ToPOSIX(pattern)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s ~ %s", left, v.String(translated)), nil
+	}
+
+	return fmt.Sprintf("%s SIMILAR TO %s", left, v.String(wildcard.Parse(pattern).SimilarTo('\\'))), nil
+}
+
+// patternOf returns the wildcard or the regexp between the slashes on the right side of a LIKE
+func patternOf(right *expr.Expression) (string, error) {
+	pattern := fmt.Sprintf("%v", right.Left)
+	if err := validString(pattern); err != nil {
+		return "", err
+	}
+	if right.Op != expr.Regexp {
+		return pattern, nil
+	}
+	if len(pattern) < 2 || pattern[0] != '/' || pattern[len(pattern)-1] != '/' {
+		return "", fmt.Errorf("unable to render the regexp %s, it must be between slashes", pattern)
+	}
+	return pattern[1 : len(pattern)-1], nil
+}
+
+// quote serializes a string value
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func inFn(left, right string) (string, error) {
+	return fmt.Sprintf("%s IN %s", left, right), nil
+}
+
+func list(left, right string) (string, error) {
+	return fmt.Sprintf("(%s)", left), nil
+}
+
+func greater(left, right string) (string, error) {
+	return fmt.Sprintf("%s > %s", left, right), nil
+}
+
+func less(left, right string) (string, error) {
+	return fmt.Sprintf("%s < %s", left, right), nil
+}
+
+func greaterEq(left, right string) (string, error) {
+	return fmt.Sprintf("%s >= %s", left, right), nil
+}
+
+func lessEq(left, right string) (string, error) {
+	return fmt.Sprintf("%s <= %s", left, right), nil
+}
+
+// rang renders a range as the comparisons of its bounds. Numbers are compared as numbers and
+// anything else as the string it is, an unbounded side is left out and a range without any bound
+// only needs the column to be set.
+func rang(left, right string) (string, error) {
+	rawMin, rawMax, inclusive, err := rangeBounds(right)
+	if err != nil {
+		return "", err
+	}
+
+	lower, upper := ">", "<"
+	if inclusive {
+		lower, upper = ">=", "<="
+	}
+
+	bounds := []string{}
+	if rawMin != "'*'" {
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", left, lower, number(rawMin)))
+	}
+	if rawMax != "'*'" {
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", left, upper, number(rawMax)))
+	}
+	if len(bounds) == 0 {
+		return fmt.Sprintf("%s IS NOT NULL", left), nil
+	}
+	return strings.Join(bounds, " AND "), nil
+}
+
+// rangeBounds splits a serialized range into its serialized bounds. An unbounded side is '*'.
+func rangeBounds(right string) (rawMin, rawMax string, inclusive bool, err error) {
+	if len(right) < 2 {
+		return "", "", false, fmt.Errorf("the BETWEEN operator needs a two item list in the right hand side, have %s", right)
+	}
+	inclusive = true
+	if right[0] == '(' && right[len(right)-1] == ')' {
+		inclusive = false
+	}
+
+	rangeSlice := splitBounds(right[1 : len(right)-1])
+	if len(rangeSlice) != 2 {
+		return "", "", false, fmt.Errorf("the BETWEEN operator needs a two item list in the right hand side, have %s", right)
+	}
+	return strings.Trim(rangeSlice[0], " "), strings.Trim(rangeSlice[1], " "), inclusive, nil
+}
+
+// number normalizes a numeric bound so it is rendered the same however it was written. Anything
+// else is returned as is.
+func number(raw string) string {
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return raw
+}
+
+// splitBounds splits the serialized bounds of a range on the commas that aren't inside a quoted
+// string
+func splitBounds(s string) []string {
+	out := []string{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, s[start:])
+}
+
+func basicCompound(op expr.Operator) RenderFN {
+	return func(left, right string) (string, error) {
+		return fmt.Sprintf("%s %s %s", left, op, right), nil
+	}
+}
+
+func basicWrap(op expr.Operator) RenderFN {
+	return func(left, right string) (string, error) {
+		return fmt.Sprintf("%s(%s)", op, left), nil
+	}
+}
@@ -0,0 +1,313 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/params"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// SQLiteDriver transforms a parsed lucene expression to a SQLite filter. Wildcards are rendered
+// with GLOB and regexps with REGEXP, which calls the regexp(pattern, value) function the
+// application registers. The patterns are translated to RE2 so the function can be backed by the
+// go regexp package.
+type SQLiteDriver struct {
+	Base
+}
+
+// FTS5 is a full text index the free text terms and phrases are matched against
+type FTS5 struct {
+	// Table is the FTS5 virtual table
+	Table string
+	// Columns are the fields indexed by the table. Terms and phrases on them are matched against
+	// the index as well.
+	Columns []string
+	// Key is the column of the filtered table that holds the rowid of the index, rowid by default
+	Key string
+}
+
+// SQLiteOpt configures the SQLite driver
+type SQLiteOpt func(*sqliteConfig)
+
+type sqliteConfig struct {
+	fts  *FTS5
+	like bool
+}
+
+// WithFTS5 routes the free text terms and phrases to the FTS5 table as a MATCH expression
+func WithFTS5(fts FTS5) SQLiteOpt {
+	return func(c *sqliteConfig) {
+		c.fts = &fts
+	}
+}
+
+// WithLikeWildcards renders wildcards with LIKE instead of GLOB, which makes them case insensitive
+// for ascii characters.
+func WithLikeWildcards() SQLiteOpt {
+	return func(c *sqliteConfig) {
+		c.like = true
+	}
+}
+
+// NewSQLiteDriver creates a new driver that will output a parsed lucene expression as a SQLite filter.
+func NewSQLiteDriver(opts ...SQLiteOpt) SQLiteDriver {
+	c := &sqliteConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	fns := map[expr.Operator]RenderFN{
+		expr.Literal: literal,
+	}
+	valueFNs := map[expr.Operator]ValueFN{
+		expr.Like: sqliteGlob,
+	}
+	if c.like {
+		valueFNs[expr.Like] = sqliteLike
+	}
+
+	for op, sharedFN := range Shared {
+		_, found := fns[op]
+		if !found {
+			fns[op] = sharedFN
+		}
+	}
+
+	d := SQLiteDriver{
+		Base{
+			RenderFNs:   fns,
+			ValueFNs:    valueFNs,
+			QuoteColumn: sqliteColumn,
+			Params:      params.Question,
+		},
+	}
+	if c.fts != nil {
+		d.Intercept = c.fts.intercept
+	}
+	return d
+}
+
+// sqliteColumn quotes a column name with double quotes, double quotes in the name are doubled
+func sqliteColumn(name string) (string, error) {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+func sqliteGlob(left string, right *expr.Expression, v Values) (string, error) {
+	return sqliteMatch(left, right, v, func(p wildcard.Pattern) string {
+		return fmt.Sprintf("%s GLOB %s", left, v.String(p.Glob()))
+	})
+}
+
+func sqliteLike(left string, right *expr.Expression, v Values) (string, error) {
+	return sqliteMatch(left, right, v, func(p wildcard.Pattern) string {
+		return fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, left, v.String(p.Like('\\')))
+	})
+}
+
+// sqliteMatch renders regexps with REGEXP and wildcards with the wildcard renderer
+func sqliteMatch(left string, right *expr.Expression, v Values, wild func(p wildcard.Pattern) string) (string, error) {
+	pattern, err := patternOf(right)
+	if err != nil {
+		return "", err
+	}
+	if right.Op == expr.Regexp {
+		translated, err := regex.ToRE2(pattern)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s REGEXP %s", left, v.String(translated)), nil
+	}
+	return wild(wildcard.Parse(pattern)), nil
+}
+
+// intercept renders the largest filters that only match free text as a lookup in the index
+func (f FTS5) intercept(e *expr.Expression, v Values) (string, bool, error) {
+	q, ok, err := f.query(e)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	key := f.Key
+	if key == "" {
+		key = "rowid"
+	}
+	table, err := sqliteColumn(f.Table)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("%s IN (SELECT rowid FROM %s WHERE %s MATCH %s)", key, table, table, v.String(q)), true, nil
+}
+
+// query translates the expression into an FTS5 query. It isn't ok when the expression has parts
+// that aren't free text or terms on the indexed columns. Free text the index can't match, like a
+// regexp, is an error.
+func (f FTS5) query(e *expr.Expression) (q string, ok bool, err error) {
+	if e == nil {
+		return "", false, nil
+	}
+
+	switch e.Op {
+	case expr.Literal:
+		if _, isColumn := e.Left.(expr.Column); isColumn {
+			return "", false, nil
+		}
+		return ftsPhrase(e.Left), true, nil
+	case expr.Wild:
+		q, err := ftsPrefix(e.Left)
+		return q, err == nil, err
+	case expr.Regexp:
+		return "", false, fmt.Errorf("unable to match the regexp %v against the FTS5 index", e.Left)
+	case expr.Fuzzy:
+		return f.near(e)
+	case expr.Equals, expr.Like, expr.In:
+		return f.column(e)
+	case expr.Must:
+		return f.query(asExpr(e.Left))
+	case expr.Or:
+		left, lok, err := f.query(asExpr(e.Left))
+		if err != nil || !lok {
+			return "", false, err
+		}
+		right, rok, err := f.query(asExpr(e.Right))
+		if err != nil || !rok {
+			return "", false, err
+		}
+		return fmt.Sprintf("(%s) OR (%s)", left, right), true, nil
+	case expr.And:
+		// fts5 has no unary NOT so a negated side becomes the right side of a binary NOT
+		leftExpr, rightExpr := asExpr(e.Left), asExpr(e.Right)
+		if isNegation(leftExpr) && !isNegation(rightExpr) {
+			leftExpr, rightExpr = rightExpr, leftExpr
+		}
+		op := "AND"
+		if isNegation(rightExpr) {
+			op = "NOT"
+			rightExpr = asExpr(rightExpr.Left)
+		}
+
+		left, lok, err := f.query(leftExpr)
+		if err != nil || !lok {
+			return "", false, err
+		}
+		right, rok, err := f.query(rightExpr)
+		if err != nil || !rok {
+			return "", false, err
+		}
+		return fmt.Sprintf("(%s) %s (%s)", left, op, right), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// near translates a proximity search on a phrase into a NEAR group of its terms
+func (f FTS5) near(e *expr.Expression) (string, bool, error) {
+	sub := asExpr(e.Left)
+	if sub == nil {
+		return "", false, nil
+	}
+
+	prefix := ""
+	if sub.Op == expr.Equals {
+		column, isIndexed := f.indexed(sub.Left)
+		if !isIndexed {
+			return "", false, nil
+		}
+		prefix = column + " : "
+		sub = asExpr(sub.Right)
+	}
+	if sub == nil || sub.Op != expr.Literal {
+		return "", false, nil
+	}
+
+	terms := strings.Fields(fmt.Sprintf("%v", sub.Left))
+	if len(terms) < 2 {
+		return "", false, fmt.Errorf("unable to match the fuzzy term %v against the FTS5 index", sub.Left)
+	}
+	phrases := []string{}
+	for _, t := range terms {
+		phrases = append(phrases, ftsPhrase(t))
+	}
+	return fmt.Sprintf("%sNEAR(%s, %d)", prefix, strings.Join(phrases, " "), e.FuzzyDistance()), true, nil
+}
+
+// column translates a term, prefix or list of terms on an indexed column into a column filter
+func (f FTS5) column(e *expr.Expression) (string, bool, error) {
+	column, isIndexed := f.indexed(e.Left)
+	if !isIndexed {
+		return "", false, nil
+	}
+	right := asExpr(e.Right)
+	if right == nil {
+		return "", false, nil
+	}
+
+	switch {
+	case e.Op == expr.Equals && right.Op == expr.Literal:
+		return column + " : " + ftsPhrase(right.Left), true, nil
+	case e.Op == expr.Like && right.Op == expr.Wild:
+		q, err := ftsPrefix(right.Left)
+		if err != nil {
+			return "", false, nil
+		}
+		return column + " : " + q, true, nil
+	case e.Op == expr.In && right.Op == expr.List:
+		items, isList := right.Left.([]*expr.Expression)
+		if !isList {
+			return "", false, nil
+		}
+		phrases := []string{}
+		for _, item := range items {
+			if item == nil || item.Op != expr.Literal {
+				return "", false, nil
+			}
+			phrases = append(phrases, ftsPhrase(item.Left))
+		}
+		return column + " : (" + strings.Join(phrases, " OR ") + ")", true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// indexed returns the fts5 column filter of a column that is indexed by the table
+func (f FTS5) indexed(in any) (string, bool) {
+	e := asExpr(in)
+	if e == nil {
+		return "", false
+	}
+	column, isColumn := e.Left.(expr.Column)
+	if !isColumn {
+		return "", false
+	}
+	for _, c := range f.Columns {
+		if c == string(column) {
+			return ftsPhrase(c), true
+		}
+	}
+	return "", false
+}
+
+// ftsPhrase quotes a term or phrase as an fts5 string
+func ftsPhrase(in any) string {
+	return `"` + strings.ReplaceAll(fmt.Sprintf("%v", in), `"`, `""`) + `"`
+}
+
+// ftsPrefix translates a wildcard into an fts5 prefix query, the only wildcard fts5 supports
+func ftsPrefix(in any) (string, error) {
+	prefix, isPrefix := wildcard.Parse(fmt.Sprintf("%v", in)).Prefix()
+	if !isPrefix {
+		return "", fmt.Errorf("unable to match the wildcard %v against the FTS5 index, only prefixes like abc* are supported", in)
+	}
+	return ftsPhrase(prefix) + " *", nil
+}
+
+func isNegation(e *expr.Expression) bool {
+	return e != nil && (e.Op == expr.Not || e.Op == expr.MustNot)
+}
+
+func asExpr(in any) *expr.Expression {
+	e, _ := in.(*expr.Expression)
+	return e
+}
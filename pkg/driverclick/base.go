@@ -2,9 +2,8 @@ package driverclick
 
 import (
 	"fmt"
-	// "strconv"
-	"strings"
 
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
 	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
 )
 
@@ -24,17 +23,49 @@ var Shared = map[expr.Operator]RenderFN{
 	expr.Wild:      literal,
 	expr.Regexp:    literal,
 	expr.Like:      like,
-	expr.Greater:   greater,
-	expr.GreaterEq: greaterEq,
-	expr.Less:      less,
-	expr.LessEq:    lessEq,
+	expr.Greater:   compare(">"),
+	expr.GreaterEq: compare(">="),
+	expr.Less:      compare("<"),
+	expr.LessEq:    compare("<="),
 	expr.In:        inFn,
-	expr.List:      list,
 }
 
 // Base is the base driver that is embedded in each driver
 type Base struct {
 	RenderFNs map[expr.Operator]RenderFN
+
+	// Analyzers, if set, split the terms and phrases on the text column into the tokens the
+	// TextTokens strategy looks for with the tokenizer of the analyzer of the field. The tokens are
+	// lower cased but never stemmed or dropped as stopwords since the column holds the text as it
+	// is, and the values that are compared with LIKE or = are never analyzed.
+	Analyzers *analysis.PerField
+
+	// Fuzzy, if set, renders the fuzzy terms that weren't expanded against a term dictionary with
+	// the fuzzy package beforehand
+	Fuzzy FuzzyRenderFN
+
+	// Layout is how the fields are stored in the table, DefaultLayout when it isn't set
+	Layout Layout
+	// TextColumn is the column the _source field matches, the full text of the document. It is
+	// _source by default.
+	TextColumn string
+	// TextStrategy is how the terms and phrases on the text column are matched, TextLike by default
+	TextStrategy TextStrategy
+	// FieldColumns are the columns of the fields that are stored apart from the layout, they are
+	// rendered as they are
+	FieldColumns map[string]string
+	// Materialized are the columns the fields are promoted to. They are compared directly, without
+	// lower casing or substring matching, so their skip indexes can be used.
+	Materialized map[string]string
+
+	// MatchMode is how the terms and phrases on string fields are matched, MatchLike by default.
+	// The materialized columns are matched exactly.
+	MatchMode MatchMode
+	// FieldMatchModes are the match modes of the fields that are matched differently
+	FieldMatchModes map[string]MatchMode
+
+	// args collects the query parameters of RenderParams
+	args *[]any
 }
 
 // Render will render the expression based on the renderFNs provided by the driver.
@@ -43,46 +74,88 @@ func (b Base) Render(e *expr.Expression) (s string, err error) {
 		return "", nil
 	}
 
-	// if b.isColumn(left) {
-	// 	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-	// 		left = "numbers.value[indexOf(numbers.name, " + left + ")]"
-	// 	} else if _, err := strconv.ParseBool(right); err == nil {
-	// 		left = "bools.value[indexOf(bools.name, " + left + ")]"
-	// 	} else {
-	// 		left = "strings.value[indexOf(strings.name, " + left + ")]"
-	// 	}
-	// }
-
-	left, err := b.serialize(e.Left)
-	if err != nil {
-		return s, err
+	if e.Op == expr.Fuzzy {
+		return b.renderFuzzy(e)
 	}
 
-	right, err := b.serialize(e.Right)
-	if err != nil {
-		return s, err
+	// sql has no optional clauses so boolean queries are rendered as the filter they are equivalent to
+	if e.Op == expr.Bool {
+		return b.Render(expr.ToFilter(e))
 	}
 
-	if e.Op != expr.Range && e.Op != expr.Not && e.Op != expr.List && e.Op != expr.In && e.Op != expr.Literal && e.Op != expr.Must && e.Op != expr.MustNot {
-		if !b.isSimple(e.Left) {
-			left = "(" + left + ")"
+	if b.TextStrategy == TextTokens {
+		// free text is matched against the text column and the terms of an OR on it are
+		// searched at once
+		if isFreeText(e) {
+			e = expr.Eq("_source", e)
 		}
-		if !b.isSimple(e.Right) {
-			right = "(" + right + ")"
+		if terms, ok := b.textTerms(e); ok && e.Op == expr.Or {
+			return b.renderNode(Node{Op: expr.In, Field: "_source", Values: terms})
 		}
 	}
 
-	fn, ok := b.RenderFNs[e.Op]
+	n, err := b.node(e)
+	if err != nil {
+		return s, err
+	}
+	return b.renderNode(n)
+}
+
+// renderNode renders a node with the render function of its operator
+func (b Base) renderNode(n Node) (string, error) {
+	fn, ok := b.RenderFNs[n.Op]
 	if !ok {
-		return s, fmt.Errorf("unable to render operator [%s]", e.Op)
+		return "", fmt.Errorf("unable to render operator [%s]", n.Op)
 	}
+	n.args = b.args
+	b.resolve(&n)
+	return fn(n)
+}
 
-	return fn(left, right)
+// RenderParams renders the expression like Render but with {p1:String} query parameters in place of
+// the string values, the arguments are the values of p1, p2... in order. The columns, the field
+// names of the layout and the numbers are written as they are.
+func (b Base) RenderParams(e *expr.Expression) (s string, args []any, err error) {
+	b.args = &[]any{}
+	s, err = b.Render(e)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, *b.args, nil
+}
+
+func (b Base) renderFuzzy(e *expr.Expression) (s string, err error) {
+	if b.Fuzzy == nil {
+		return s, fmt.Errorf("unable to render operator [%s], expand the fuzzy terms first or set a fuzzy renderer", e.Op)
+	}
+
+	sub, isExpr := e.Left.(*expr.Expression)
+	if !isExpr || sub == nil || sub.Op != expr.Equals {
+		return s, fmt.Errorf("unable to render operator [%s] without a field", e.Op)
+	}
+	field, err := fieldOf(sub)
+	if err != nil {
+		return s, fmt.Errorf("unable to render operator [%s] without a field", e.Op)
+	}
+	term, err := valueOf(sub.Right)
+	if err != nil {
+		return s, err
+	}
+	if term.Kind != String {
+		return s, fmt.Errorf("unable to render operator [%s] on %s, it must be a string term", e.Op, term)
+	}
+	n := Node{Op: e.Op, Field: field, Value: term, Distance: e.FuzzyDistance(), args: b.args}
+	b.resolve(&n)
+	return b.Fuzzy(n)
 }
 
 func (b Base) isSimple(in any) bool {
 	switch v := in.(type) {
 	case *expr.Expression:
+		if b.TextStrategy == TextTokens && v != nil && isFreeText(v) {
+			// free text is rendered as a match of the text column
+			return false
+		}
 		return v.Op == expr.Undefined || v.Op == expr.Literal || v.Op == expr.Regexp || v.Op == expr.Wild
 	case expr.Column:
 		return true
@@ -95,64 +168,18 @@ func (b Base) isSimple(in any) bool {
 	}
 }
 
-func (b Base) isColumn(in any) bool {
-	switch in.(type) {
-	case expr.Column:
-		return true
-	default:
-		return false
-	}
-}
-
+// serialize renders an operand of NOT, MUST and MUST_NOT
 func (b Base) serialize(in any) (s string, err error) {
 	if in == nil {
 		return "", nil
 	}
 
-	switch v := in.(type) {
-	case *expr.Expression:
-		return b.Render(v)
-	case []*expr.Expression:
-		strs := []string{}
-		for _, e := range v {
-			s, err = b.Render(e)
-			if err != nil {
-				return s, err
-			}
-			strs = append(strs, s)
-		}
-		return strings.Join(strs, ", "), nil
-	case *expr.RangeBoundary:
-		min, err := b.serialize(v.Min)
-		if err != nil {
-			return "", err
-		}
-		max, err := b.serialize(v.Max)
-		if err != nil {
-			return "", err
-		}
-
-		if v.Inclusive {
-			return fmt.Sprintf("[%s, %s]", min, max), nil
-		}
-		return fmt.Sprintf("(%s, %s)", min, max), nil
-
-	case expr.Column:
-		if len(v) == 0 {
-			return "", fmt.Errorf("column name is empty")
-		}
-		if strings.ContainsRune(string(v), '"') {
-			return "", fmt.Errorf("column name contains a double quote: %q", v)
-		}
-		// Always escape column names with double quotes,
-		// otherwise we need to know the reserved words
-		// which might change in the future.
-		// For clickhouse fields must be in single quotes
-		return fmt.Sprintf(`'%s'`, string(v)), nil
-	case string:
-		// escape single quotes with double single quotes
-		return fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''")), nil
-	default:
-		return fmt.Sprintf("%v", v), nil
+	if e, isExpr := in.(*expr.Expression); isExpr {
+		return b.Render(e)
+	}
+	v, err := valueOf(in)
+	if err != nil {
+		return "", err
 	}
+	return Node{args: b.args}.SQL(v), nil
 }
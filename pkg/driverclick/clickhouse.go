@@ -1,14 +1,91 @@
 package driverclick
 
-import "github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+import (
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
 
-// PostgresDriver transforms a parsed lucene expression to a sql filter.
+// ClickhouseDriver transforms a parsed lucene expression to a ClickHouse filter.
 type ClickhouseDriver struct {
 	Base
 }
 
-// NewPostgresDriver creates a new driver that will output a parsed lucene expression as a SQL filter.
-func NewClickhouseDriver() ClickhouseDriver {
+// ClickhouseOpt configures the ClickHouse driver
+type ClickhouseOpt func(*ClickhouseDriver)
+
+// WithLayout sets how the fields are stored in the table
+func WithLayout(layout Layout) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		d.Layout = layout
+	}
+}
+
+// WithTextColumn sets the column the _source field matches
+func WithTextColumn(column string) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		d.TextColumn = column
+	}
+}
+
+// WithTextStrategy sets how the terms and phrases on the text column are matched
+func WithTextStrategy(strategy TextStrategy) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		d.TextStrategy = strategy
+	}
+}
+
+// WithAnalyzers splits the terms and phrases on the text column into the tokens the TextTokens
+// strategy looks for with the tokenizers of the analyzers, their filters are not applied
+func WithAnalyzers(analyzers analysis.PerField) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		d.Analyzers = &analyzers
+	}
+}
+
+// WithFieldColumn stores a field in a column of its own instead of the layout
+func WithFieldColumn(field, column string) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		if d.FieldColumns == nil {
+			d.FieldColumns = map[string]string{}
+		}
+		d.FieldColumns[field] = column
+	}
+}
+
+// WithMaterializedColumns promotes the fields to the columns they are mapped to. Terms on them are
+// matched exactly with = and wildcards with a case sensitive LIKE, so the skip indexes of the
+// columns can be used.
+func WithMaterializedColumns(columns map[string]string) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		if d.Materialized == nil {
+			d.Materialized = map[string]string{}
+		}
+		for field, column := range columns {
+			d.Materialized[field] = column
+		}
+	}
+}
+
+// WithMatchMode sets how the terms and phrases on string fields are matched
+func WithMatchMode(mode MatchMode) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		d.MatchMode = mode
+	}
+}
+
+// WithFieldMatchMode sets how the terms and phrases on a field are matched, it takes precedence
+// over the global match mode and the exact match of the materialized columns
+func WithFieldMatchMode(field string, mode MatchMode) ClickhouseOpt {
+	return func(d *ClickhouseDriver) {
+		if d.FieldMatchModes == nil {
+			d.FieldMatchModes = map[string]MatchMode{}
+		}
+		d.FieldMatchModes[field] = mode
+	}
+}
+
+// NewClickhouseDriver creates a new driver that will output a parsed lucene expression as a ClickHouse filter.
+func NewClickhouseDriver(opts ...ClickhouseOpt) ClickhouseDriver {
 	fns := map[expr.Operator]RenderFN{
 		expr.Literal: literal,
 	}
@@ -20,9 +97,13 @@ func NewClickhouseDriver() ClickhouseDriver {
 		}
 	}
 
-	return ClickhouseDriver{
+	d := ClickhouseDriver{
 		Base{
 			RenderFNs: fns,
 		},
 	}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
 }
@@ -0,0 +1,126 @@
+package driverclick
+
+import (
+	"strings"
+)
+
+// Columns are the expressions that read the value of a field as each type
+type Columns struct {
+	String string
+	Number string
+	Bool   string
+}
+
+// Layout is how the fields are stored in the table
+type Layout interface {
+	// Columns returns the expressions that read the value of the field
+	Columns(field string) Columns
+}
+
+// Arrays are the parallel arrays of the names and the values of the fields
+type Arrays struct {
+	Names  string
+	Values string
+}
+
+func (a Arrays) lookup(field string) string {
+	return a.Values + "[indexOf(" + a.Names + "," + quoteString(field) + ")]"
+}
+
+// ArrayLayout stores the strings, numbers and booleans in their own pair of parallel arrays
+type ArrayLayout struct {
+	Strings Arrays
+	Numbers Arrays
+	Bools   Arrays
+}
+
+// DefaultLayout is the layout of the strings, numbers and bools Nested columns with the name and
+// value arrays
+var DefaultLayout = ArrayLayout{
+	Strings: Arrays{Names: "strings.name", Values: "strings.value"},
+	Numbers: Arrays{Names: "numbers.name", Values: "numbers.value"},
+	Bools:   Arrays{Names: "bools.name", Values: "bools.value"},
+}
+
+// Columns returns the lookups of the field in the arrays
+func (l ArrayLayout) Columns(field string) Columns {
+	return Columns{
+		String: l.Strings.lookup(field),
+		Number: l.Numbers.lookup(field),
+		Bool:   l.Bools.lookup(field),
+	}
+}
+
+// NestedLayout stores every field as a string in a Nested(key String, value String) column
+type NestedLayout struct {
+	Column string
+	// Key and Value are the names of the subcolumns, key and value by default
+	Key   string
+	Value string
+}
+
+// Columns returns the lookup of the field in the nested column
+func (l NestedLayout) Columns(field string) Columns {
+	key, value := l.Key, l.Value
+	if key == "" {
+		key = "key"
+	}
+	if value == "" {
+		value = "value"
+	}
+	return fromString(Arrays{Names: l.Column + "." + key, Values: l.Column + "." + value}.lookup(field))
+}
+
+// MapLayout stores every field as a string in a Map(String, String) column
+type MapLayout struct {
+	Column string
+}
+
+// Columns returns the value of the key of the field
+func (l MapLayout) Columns(field string) Columns {
+	return fromString(l.Column + "[" + quoteString(field) + "]")
+}
+
+// JSONLayout stores the fields in a JSON column, the dots of a field are the levels of its path
+type JSONLayout struct {
+	Column string
+}
+
+// Columns returns the path of the field cast to each type
+func (l JSONLayout) Columns(field string) Columns {
+	path := []string{l.Column}
+	for _, part := range strings.Split(field, ".") {
+		path = append(path, quoteIdentifier(part))
+	}
+	column := strings.Join(path, ".")
+	return Columns{
+		String: column + "::String",
+		Number: column + "::Float64",
+		Bool:   column + "::Bool",
+	}
+}
+
+// NativeLayout stores every field in a column of its own with the name of the field
+type NativeLayout struct{}
+
+// Columns returns the column of the field
+func (NativeLayout) Columns(field string) Columns {
+	column := quoteIdentifier(field)
+	return Columns{String: column, Number: column, Bool: column}
+}
+
+// fromString reads numbers and booleans from a string value
+func fromString(column string) Columns {
+	return Columns{
+		String: column,
+		Number: "toFloat64OrNull(" + column + ")",
+		Bool:   "toBool(" + column + ")",
+	}
+}
+
+var identifierEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// quoteIdentifier quotes a column name with backticks
+func quoteIdentifier(name string) string {
+	return "`" + identifierEscaper.Replace(name) + "`"
+}
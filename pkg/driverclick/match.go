@@ -0,0 +1,79 @@
+package driverclick
+
+import "fmt"
+
+// MatchMode is how a term or phrase on a string field is matched
+type MatchMode int
+
+const (
+	// MatchLike matches the values that contain the term ignoring the case with
+	// lowerUTF8(value) like lowerUTF8('%term%'). The %, _ and backslashes of the term are escaped
+	// so they match themselves.
+	MatchLike MatchMode = iota
+	// Exact matches the values that are the term
+	Exact
+	// ExactCaseInsensitive matches the values that are the term ignoring the case
+	ExactCaseInsensitive
+	// Prefix matches the values that start with the term
+	Prefix
+	// Substring matches the values that contain the term ignoring the case
+	Substring
+	// Token matches the values that have the term as a token, it uses the tokenbf_v1 skip indexes.
+	// The term can only have letters and digits.
+	Token
+)
+
+var matchModeToString = map[MatchMode]string{
+	MatchLike:            "like",
+	Exact:                "exact",
+	ExactCaseInsensitive: "case insensitive exact",
+	Prefix:               "prefix",
+	Substring:            "substring",
+	Token:                "token",
+}
+
+func (m MatchMode) String() string {
+	return matchModeToString[m]
+}
+
+// matchString renders the comparison of a string field with a term or phrase in the match mode of
+// the field
+func matchString(n Node) (string, error) {
+	column := n.Columns.String
+
+	switch n.Match {
+	case MatchLike:
+		// magic for converting into 'some text' -> '%some text%' for propper searching with like
+		return fmt.Sprintf("lowerUTF8(%s) like lowerUTF8(%s)", column, n.Quote("%"+likeEscaper.Replace(n.Value.String())+"%")), nil
+	case Exact:
+		return fmt.Sprintf("%s = %s", column, n.SQL(n.Value)), nil
+	case ExactCaseInsensitive:
+		return fmt.Sprintf("lowerUTF8(%s) = lowerUTF8(%s)", column, n.SQL(n.Value)), nil
+	case Prefix:
+		return fmt.Sprintf("startsWith(%s, %s)", column, n.SQL(n.Value)), nil
+	case Substring:
+		return fmt.Sprintf("positionCaseInsensitiveUTF8(%s, %s) > 0", column, n.SQL(n.Value)), nil
+	case Token:
+		if !isToken(n.Value.String()) {
+			return "", fmt.Errorf("unable to match %s on %s as a token, it can only have letters and digits", n.Value.SQL(), n.Field)
+		}
+		return fmt.Sprintf("hasToken(%s, %s)", column, n.SQL(n.Value)), nil
+	default:
+		return "", fmt.Errorf("unable to match %s on %s with the unknown match mode %d", n.Value.SQL(), n.Field, n.Match)
+	}
+}
+
+// isToken returns true when hasToken can look for the string. ClickHouse splits the tokens on the
+// ascii characters that aren't letters or digits.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x80 && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
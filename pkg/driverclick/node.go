@@ -0,0 +1,356 @@
+package driverclick
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/params"
+)
+
+// Kind is the type of a value
+type Kind int
+
+const (
+	// String is a term or a phrase
+	String Kind = iota
+	// Int is an integer
+	Int
+	// Float is a floating point number
+	Float
+	// Bool is a boolean, the parser never produces them but expressions built in code can have them
+	Bool
+	// Wildcard is a term with the * and ? wildcards
+	Wildcard
+	// Regexp is a lucene regexp
+	Regexp
+)
+
+var kindToString = map[Kind]string{
+	String:   "string",
+	Int:      "int",
+	Float:    "float",
+	Bool:     "bool",
+	Wildcard: "wildcard",
+	Regexp:   "regexp",
+}
+
+func (k Kind) String() string {
+	return kindToString[k]
+}
+
+// Value is a typed value of an expression
+type Value struct {
+	Kind Kind
+	// Raw is a string for strings and wildcards, the pattern between the slashes for regexps, an
+	// int, a float64 or a bool
+	Raw any
+}
+
+// IsNumber returns true when the value is an int or a float
+func (v Value) IsNumber() bool {
+	return v.Kind == Int || v.Kind == Float
+}
+
+// String returns the value the way it is written in the query
+func (v Value) String() string {
+	switch raw := v.Raw.(type) {
+	case string:
+		if v.Kind == Regexp {
+			return "/" + raw + "/"
+		}
+		return raw
+	case int:
+		return strconv.Itoa(raw)
+	case float64:
+		return strconv.FormatFloat(raw, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(raw)
+	default:
+		return fmt.Sprintf("%v", raw)
+	}
+}
+
+// SQL returns the value as a ClickHouse literal. Numbers and booleans are written as they are and
+// everything else is a string. The render functions write the values with Node.SQL instead so
+// they can be query parameters.
+func (v Value) SQL() string {
+	switch v.Kind {
+	case Int, Float, Bool:
+		return v.String()
+	default:
+		return quoteString(v.String())
+	}
+}
+
+// Node is an expression with its operands typed, it is what the render functions get
+type Node struct {
+	Op expr.Operator
+
+	// Field is the field of a comparison, it is empty for free text
+	Field string
+	// Columns read the value of the field
+	Columns Columns
+	// FullText is set when the field is _source, the full text of the document
+	FullText bool
+	// Text is how a term or phrase on the text column is matched
+	Text TextStrategy
+	// Tokens are the tokens the tokenizer of the analyzer splits a term or phrase on the text column
+	// into, nil when there is no analyzer or the value is matched with LIKE
+	Tokens []string
+	// Materialized is set when the field is promoted to a column of its own that is compared
+	// directly
+	Materialized bool
+	// Match is how a term or phrase on the field is matched
+	Match MatchMode
+	// Value is the value the field is compared to and the term or phrase of free text
+	Value Value
+	// Values are the values of an IN
+	Values []Value
+	// Min and Max are the bounds of a range, nil when the range is unbounded on that side
+	Min, Max  *Value
+	Inclusive bool
+	// Distance is the edit distance of a fuzzy term
+	Distance int
+
+	// Left and Right are the rendered operands of AND, OR, NOT, MUST and MUST_NOT
+	Left, Right string
+
+	// args collects the string values written as query parameters, nil when they are written as
+	// literals
+	args *[]any
+}
+
+// SQL writes a value into the filter. Numbers and booleans are written as they are and everything
+// else is a string written with Quote.
+func (n Node) SQL(v Value) string {
+	switch v.Kind {
+	case Int, Float, Bool:
+		return v.String()
+	default:
+		return n.Quote(v.String())
+	}
+}
+
+// Quote writes a string value into the filter, as a {p1:String} query parameter when the
+// expression is rendered with RenderParams and as a quoted literal otherwise
+func (n Node) Quote(s string) string {
+	if n.args == nil {
+		return quoteString(s)
+	}
+	*n.args = append(*n.args, s)
+	return params.ClickHouse(len(*n.args))
+}
+
+// node types the operands of an expression. The operands of the boolean operators are rendered.
+func (b Base) node(e *expr.Expression) (n Node, err error) {
+	n.Op = e.Op
+
+	switch e.Op {
+	case expr.And, expr.Or:
+		n.Left, err = b.operand(e.Left)
+		if err != nil {
+			return n, err
+		}
+		n.Right, err = b.operand(e.Right)
+		return n, err
+	case expr.Not, expr.Must, expr.MustNot:
+		n.Left, err = b.serialize(e.Left)
+		return n, err
+	case expr.Literal, expr.Wild, expr.Regexp:
+		n.Value, err = valueOf(e)
+		return n, err
+	case expr.Equals, expr.Like, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		n.Field, err = fieldOf(e)
+		if err != nil {
+			return n, err
+		}
+		n.Value, err = valueOf(e.Right)
+		return n, err
+	case expr.In:
+		n.Field, err = fieldOf(e)
+		if err != nil {
+			return n, err
+		}
+		list, isExpr := e.Right.(*expr.Expression)
+		if !isExpr || list == nil || list.Op != expr.List {
+			return n, fmt.Errorf("unable to render operator [%s] without a list of values", e.Op)
+		}
+		items, isList := list.Left.([]*expr.Expression)
+		if !isList {
+			return n, fmt.Errorf("unable to render operator [%s] without a list of values", e.Op)
+		}
+		for _, item := range items {
+			v, err := valueOf(item)
+			if err != nil {
+				return n, err
+			}
+			n.Values = append(n.Values, v)
+		}
+		return n, nil
+	case expr.Range:
+		n.Field, err = fieldOf(e)
+		if err != nil {
+			return n, err
+		}
+		bounds, isRange := e.Right.(*expr.RangeBoundary)
+		if !isRange || bounds == nil {
+			return n, fmt.Errorf("unable to render operator [%s] without bounds", e.Op)
+		}
+		n.Inclusive = bounds.Inclusive
+		n.Min, err = boundOf(bounds.Min)
+		if err != nil {
+			return n, err
+		}
+		n.Max, err = boundOf(bounds.Max)
+		return n, err
+	default:
+		return n, fmt.Errorf("unable to render operator [%s]", e.Op)
+	}
+}
+
+// resolve sets the columns of the field of the node
+func (b Base) resolve(n *Node) {
+	if n.Field == "" {
+		return
+	}
+
+	if n.Field == "_source" {
+		text := b.TextColumn
+		if text == "" {
+			text = "_source"
+		}
+		n.FullText = true
+		n.Text = b.TextStrategy
+		n.Columns = Columns{String: text, Number: text, Bool: text}
+		if a := b.analyzer(n.Field); a != nil && n.Text == TextTokens && n.Op == expr.Equals && n.Value.Kind == String {
+			n.Tokens = tokensOf(a, n.Value.String())
+		}
+		return
+	}
+
+	n.Match = b.MatchMode
+	if column, found := b.Materialized[n.Field]; found {
+		n.Materialized = true
+		n.Match = Exact
+		n.Columns = Columns{String: column, Number: column, Bool: column}
+	} else if column, found := b.FieldColumns[n.Field]; found {
+		n.Columns = Columns{String: column, Number: column, Bool: column}
+	} else {
+		layout := b.Layout
+		if layout == nil {
+			layout = DefaultLayout
+		}
+		n.Columns = layout.Columns(n.Field)
+	}
+
+	if mode, found := b.FieldMatchModes[n.Field]; found {
+		n.Match = mode
+	}
+}
+
+// operand renders a side of AND and OR, the sides that aren't simple are parenthesized
+func (b Base) operand(in any) (string, error) {
+	s, err := b.serialize(in)
+	if err != nil {
+		return "", err
+	}
+	if !b.isSimple(in) {
+		s = "(" + s + ")"
+	}
+	return s, nil
+}
+
+// fieldOf returns the field on the left side of a comparison
+func fieldOf(e *expr.Expression) (string, error) {
+	in := e.Left
+	if left, isExpr := in.(*expr.Expression); isExpr && left != nil && left.Op == expr.Literal {
+		in = left.Left
+	}
+
+	column, isColumn := in.(expr.Column)
+	if !isColumn {
+		return "", fmt.Errorf("unable to render operator [%s] without a field", e.Op)
+	}
+	if len(column) == 0 {
+		return "", fmt.Errorf("column name is empty")
+	}
+	if strings.ContainsRune(string(column), '"') {
+		return "", fmt.Errorf("column name contains a double quote: %q", column)
+	}
+	if err := validString(string(column)); err != nil {
+		return "", err
+	}
+	return string(column), nil
+}
+
+// valueOf types a term, phrase, number, wildcard or regexp
+func valueOf(in any) (Value, error) {
+	e, isExpr := in.(*expr.Expression)
+	if !isExpr {
+		return rawValue(in)
+	}
+	if e == nil {
+		return Value{}, fmt.Errorf("unable to render an empty value")
+	}
+
+	switch e.Op {
+	case expr.Literal:
+		return rawValue(e.Left)
+	case expr.Wild:
+		s, isStr := e.Left.(string)
+		if !isStr {
+			return Value{}, fmt.Errorf("unable to render the wildcard %v of type %T", e.Left, e.Left)
+		}
+		return Value{Kind: Wildcard, Raw: s}, validString(s)
+	case expr.Regexp:
+		s, isStr := e.Left.(string)
+		if !isStr || len(s) < 2 || s[0] != '/' || s[len(s)-1] != '/' {
+			return Value{}, fmt.Errorf("unable to render the regexp %v, it must be between slashes", e.Left)
+		}
+		return Value{Kind: Regexp, Raw: s[1 : len(s)-1]}, validString(s)
+	default:
+		return Value{}, fmt.Errorf("unable to render %s as a value", e)
+	}
+}
+
+func rawValue(in any) (Value, error) {
+	switch v := in.(type) {
+	case string:
+		return Value{Kind: String, Raw: v}, validString(v)
+	case int:
+		return Value{Kind: Int, Raw: v}, nil
+	case float64:
+		return Value{Kind: Float, Raw: v}, nil
+	case bool:
+		return Value{Kind: Bool, Raw: v}, nil
+	case expr.Column:
+		return Value{}, fmt.Errorf("unable to render the field %s without a value", v)
+	default:
+		return Value{}, fmt.Errorf("unable to render the value %v of type %T", in, in)
+	}
+}
+
+// boundOf types the bound of a range, the * of an unbounded side is nil
+func boundOf(in any) (*Value, error) {
+	v, err := valueOf(in)
+	if err != nil {
+		return nil, err
+	}
+	if (v.Kind == String || v.Kind == Wildcard) && v.Raw == "*" {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+func validString(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("literal contains invalid utf8: %q", s)
+	}
+	if strings.ContainsRune(s, 0) {
+		return fmt.Errorf("literal contains null byte: %q", s)
+	}
+	return nil
+}
@@ -4,259 +4,214 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
 )
 
-// RenderFN is a rendering function. It takes the left and right side of the operator serialized to a string
-// and serializes the entire expression
-type RenderFN func(left, right string) (string, error)
+// RenderFN is a rendering function. It takes the expression with its operands typed and
+// serializes the entire expression
+type RenderFN func(n Node) (string, error)
 
-func literal(left, right string) (string, error) {
-	if !utf8.ValidString(left) {
-		return "", fmt.Errorf("literal contains invalid utf8: %q", left)
-	}
-	if strings.ContainsRune(left, 0) {
-		return "", fmt.Errorf("literal contains null byte: %q", left)
-	}
-	return left, nil
-}
+// FuzzyRenderFN renders a fuzzy term. It reads the column of the field from Columns.String, the
+// term from Value and the edit distance from Distance.
+type FuzzyRenderFN func(n Node) (string, error)
 
-func equals(left, right string) (string, error) {
-
-	if left == "'_source'" {
-		if strings.HasPrefix(right, "'") && strings.HasSuffix(right, "'") {
-			// magic for converting into 'some text' -> '%some text%' for propper searching with like
-			if len(right) > 1 && right[0] == '\'' && right[len(right)-1] == '\'' {
-				right = "'%" + right[1:len(right)-1] + "%'"
-			}
+// EditDistanceFuzzy renders fuzzy terms with editDistanceUTF8 on the lower cased value. Unlike
+// lucene it compares the whole value instead of its tokens and a transposition is two edits, so
+// expanding the terms with the fuzzy package is more accurate when a term dictionary is available.
+func EditDistanceFuzzy(n Node) (string, error) {
+	return fmt.Sprintf("editDistanceUTF8(lowerUTF8(%s), lowerUTF8(%s)) <= %d", n.Columns.String, n.SQL(n.Value), n.Distance), nil
+}
 
-			return fmt.Sprintf(`lowerUTF8(_source) like lowerUTF8(%s)`, right), nil
-		}
-		return fmt.Sprintf("lowerUTF8(_source) like lowerUTF8('%%%s%%')", right), nil
-	} else if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-		return fmt.Sprintf("%s = %s", left, right), nil
-	} else if _, err := strconv.ParseBool(right); err == nil {
-		left = "bools.value[indexOf(bools.name," + left + ")]"
-		return fmt.Sprintf("%s = %s", left, right), nil
-	} else {
-		if right == "''" {
-			return fmt.Sprintf("strings.value[indexOf(strings.name,%v)] = ''", left), nil
+// NgramDistanceFuzzy renders fuzzy terms with ngramDistanceCaseInsensitiveUTF8. The distance of the
+// fuzzy term is ignored, the values match if their ngram distance to the term is at most the
+// threshold which is between 0 (identical) and 1.
+func NgramDistanceFuzzy(threshold float64) FuzzyRenderFN {
+	return func(n Node) (string, error) {
+		if threshold < 0 || threshold > 1 {
+			return "", fmt.Errorf("the ngram distance threshold must be between 0 and 1, have %v", threshold)
 		}
-		// magic for converting into 'some text' -> '%some text%' for propper searching with like
-		if len(right) > 2 && right[0] == '\'' && right[len(right)-1] == '\'' {
-			right = "'%" + right[1:len(right)-1] + "%'"
-		}
-		return fmt.Sprintf("lowerUTF8(strings.value[indexOf(strings.name,%s)]) like lowerUTF8(%s)", left, right), nil
+		return fmt.Sprintf(
+			"ngramDistanceCaseInsensitiveUTF8(%s, %s) <= %s",
+			n.Columns.String,
+			n.SQL(n.Value),
+			strconv.FormatFloat(threshold, 'f', -1, 64),
+		), nil
 	}
 }
 
-func noop(left, right string) (string, error) {
-	return left, nil
+// unsupported is the error of a value an operator can't be rendered with
+func unsupported(n Node, v Value) error {
+	return fmt.Errorf("unable to render operator [%s] on the %s value %s", n.Op, v.Kind, v)
 }
 
-func like(left, right string) (string, error) {
-	if len(right) >= 4 && right[1] == '/' && right[len(right)-2] == '/' {
-		right = strings.Replace(right, "'/", "'", 1)
-		right = strings.Replace(right, "/'", "'", 1)
-
-		if left == "'_source'" {
-			left = strings.Replace(left, "'", "", -1)
-			return fmt.Sprintf("match(lowerUTF8(%s),lowerUTF8(%s))", left, right), nil
-		}
-		return fmt.Sprintf("match(lowerUTF8(strings.value[indexOf(strings.name,%s)]),lowerUTF8(%s))", left, right), nil
-	}
-
-	right = strings.ReplaceAll(right, "*", "%")
-	right = strings.ReplaceAll(right, "?", "_")
-	return fmt.Sprintf("lowerUTF8(strings.value[indexOf(strings.name,%s)]) like lowerUTF8(%s)", left, right), nil
+// literal renders free text as the value it is
+func literal(n Node) (string, error) {
+	return n.SQL(n.Value), nil
 }
 
-func inFn(left, right string) (string, error) {
-	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-	} else if _, err := strconv.ParseBool(right); err == nil {
-		left = "bools.value[indexOf(bools.name," + left + ")]"
-	} else {
-		left = "strings.value[indexOf(strings.name," + left + ")]"
+func equals(n Node) (string, error) {
+	switch {
+	case n.FullText:
+		return matchText(n)
+	case n.Value.IsNumber():
+		return fmt.Sprintf("%s = %s", n.Columns.Number, n.SQL(n.Value)), nil
+	case n.Value.Kind == Bool:
+		return fmt.Sprintf("%s = %s", n.Columns.Bool, n.SQL(n.Value)), nil
+	case n.Value.Kind == String:
+		if n.Value.Raw == "" {
+			return fmt.Sprintf("%s = ''", n.Columns.String), nil
+		}
+		return matchString(n)
+	default:
+		return like(n)
 	}
-	return fmt.Sprintf("%s IN %s", left, right), nil
 }
 
-func list(left, right string) (string, error) {
-	return fmt.Sprintf("(%s)", left), nil
+func noop(n Node) (string, error) {
+	return n.Left, nil
 }
 
-func greater(left, right string) (string, error) {
-	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-	} else {
-		return "", nil
+// like renders regexps with match() and wildcards with like. Both are translated from the lucene
+// syntax so they match the whole value and every character that isn't a lucene operator matches
+// itself. The materialized columns are matched as they are, everything else is lower cased.
+func like(n Node) (string, error) {
+	switch n.Value.Kind {
+	case Regexp:
+		translated, err := regex.ToRE2(n.Value.Raw.(string))
+		if err != nil {
+			return "", err
+		}
+		if n.Materialized {
+			return fmt.Sprintf("match(%s,%s)", n.Columns.String, n.Quote(translated)), nil
+		}
+		return fmt.Sprintf("match(lowerUTF8(%s),lowerUTF8(%s))", n.Columns.String, n.Quote(translated)), nil
+	case Wildcard, String:
+		pattern := wildcard.Parse(n.Value.String()).Like('\\')
+		if n.Materialized {
+			return fmt.Sprintf("%s like %s", n.Columns.String, n.Quote(pattern)), nil
+		}
+		return fmt.Sprintf("lowerUTF8(%s) like lowerUTF8(%s)", n.Columns.String, n.Quote(pattern)), nil
+	default:
+		return "", unsupported(n, n.Value)
 	}
-	return fmt.Sprintf("%s > %s", left, right), nil
 }
 
-func less(left, right string) (string, error) {
-	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-	} else {
-		return "", nil
-	}
-	return fmt.Sprintf("%s < %s", left, right), nil
-}
+var stringEscaper = strings.NewReplacer(`\`, `\\`, "'", "''")
 
-func greaterEq(left, right string) (string, error) {
-	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-	} else {
-		return "", nil
-	}
-	return fmt.Sprintf("%s >= %s", left, right), nil
+// quoteString serializes a string value or a pattern, single quotes are doubled and backslashes
+// are escaped since clickhouse unescapes them in string literals, so patterns reach ClickHouse as
+// they are
+func quoteString(s string) string {
+	return "'" + stringEscaper.Replace(s) + "'"
 }
 
-func lessEq(left, right string) (string, error) {
-	if _, err := strconv.ParseInt(right, 0, 64); err == nil {
-		left = "numbers.value[indexOf(numbers.name," + left + ")]"
-	} else {
-		return "", nil
+// inFn compares numbers and booleans as such when every value of the list is one, anything else is
+// compared as strings
+func inFn(n Node) (string, error) {
+	if len(n.Values) == 0 {
+		return "", fmt.Errorf("unable to render operator [%s] without values", n.Op)
 	}
-	return fmt.Sprintf("%s <= %s", left, right), nil
-}
 
-// rang is more complicated than the others because it has to handle inclusive and exclusive ranges,
-// number and string ranges, and ranges that only have one bound
-func rang(left, right string) (string, error) {
-	inclusive := true
-	if right[0] == '(' && right[len(right)-1] == ')' {
-		inclusive = false
+	numbers, bools := true, true
+	for _, v := range n.Values {
+		if v.Kind == Wildcard || v.Kind == Regexp {
+			return "", unsupported(n, v)
+		}
+		numbers = numbers && v.IsNumber()
+		bools = bools && v.Kind == Bool
 	}
 
-	stripped := right[1 : len(right)-1]
-	rangeSlice := strings.Split(stripped, ",")
-
-	if len(rangeSlice) != 2 {
-		return "", fmt.Errorf("the BETWEEN operator needs a two item list in the right hand side, have %s", right)
+	if n.FullText && n.Text == TextTokens {
+		return searchText(n)
 	}
 
-	rawMin := strings.Trim(rangeSlice[0], " ")
-	rawMax := strings.Trim(rangeSlice[1], " ")
-
-	iMin, iMax, err := toInts(rawMin, rawMax)
-	if err == nil {
-		if rawMin == "'*'" {
-			if inclusive {
-				return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] <= %d", left, iMax), nil
-			}
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] < %d", left, iMax), nil
-		}
+	column := n.Columns.String
+	switch {
+	case numbers:
+		column = n.Columns.Number
+	case bools:
+		column = n.Columns.Bool
+	}
 
-		if rawMax == "'*'" {
-			if inclusive {
-				return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] >= %d", left, iMin), nil
-			}
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] > %d", left, iMin), nil
+	values := []string{}
+	for _, v := range n.Values {
+		if numbers || bools {
+			values = append(values, n.SQL(v))
+		} else {
+			values = append(values, n.Quote(v.String()))
 		}
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.Join(values, ", ")), nil
+}
 
-		if inclusive {
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] >= %d AND numbers.value[indexOf(numbers.name,%s)] <= %d",
-					left,
-					iMin,
-					left,
-					iMax,
-				),
-				nil
+// compare renders a comparison with a number or a string
+func compare(op string) RenderFN {
+	return func(n Node) (string, error) {
+		switch n.Value.Kind {
+		case Int, Float:
+			return fmt.Sprintf("%s %s %s", n.Columns.Number, op, n.SQL(n.Value)), nil
+		case String:
+			return fmt.Sprintf("%s %s %s", n.Columns.String, op, n.SQL(n.Value)), nil
+		default:
+			return "", unsupported(n, n.Value)
 		}
-
-		return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] > %d AND numbers.value[indexOf(numbers.name,%s)] < %d",
-				left,
-				iMin,
-				left,
-				iMax,
-			),
-			nil
 	}
+}
 
-	fMin, fMax, err := toFloats(rawMin, rawMax)
-	if err == nil {
-		if rawMin == "'*'" {
-			if inclusive {
-				return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] <= %.2f", left, fMax), nil
-			}
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] < %.2f", left, fMax), nil
-		}
+// rang compares numbers when both bounds are numbers and strings otherwise. A side that is
+// unbounded is left out, inclusive string ranges bounded on both sides are rendered with BETWEEN.
+func rang(n Node) (string, error) {
+	if n.Min == nil && n.Max == nil {
+		return "", fmt.Errorf("unable to render operator [%s] on %s without any bound", n.Op, n.Field)
+	}
 
-		if rawMax == "'*'" {
-			if inclusive {
-				return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] >= %.2f", left, fMin), nil
-			}
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] > %.2f", left, fMin), nil
+	numbers := true
+	for _, v := range []*Value{n.Min, n.Max} {
+		if v == nil {
+			continue
 		}
-
-		if inclusive {
-			return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] >= %.2f AND numbers.value[indexOf(numbers.name,%s)] <= %.2f",
-					left,
-					fMin,
-					left,
-					fMax,
-				),
-				nil
+		if v.Kind != String && !v.IsNumber() {
+			return "", unsupported(n, *v)
 		}
-
-		return fmt.Sprintf("numbers.value[indexOf(numbers.name,%s)] > %.2f AND numbers.value[indexOf(numbers.name,%s)] < %.2f",
-				left,
-				fMin,
-				left,
-				fMax,
-			),
-			nil
+		numbers = numbers && v.IsNumber()
 	}
 
-	return fmt.Sprintf(`strings.value[indexOf(strings.name,%s)] BETWEEN %s AND %s`,
-			left,
-			strings.Trim(rangeSlice[0], " "),
-			strings.Trim(rangeSlice[1], " "),
-		),
-		nil
-}
-
-func basicCompound(op expr.Operator) RenderFN {
-	return func(left, right string) (string, error) {
-		return fmt.Sprintf("%s %s %s", left, op, right), nil
+	column := n.Columns.Number
+	bound := func(v *Value) string { return n.SQL(*v) }
+	if !numbers {
+		column = n.Columns.String
+		bound = func(v *Value) string { return n.Quote(v.String()) }
+		if n.Inclusive && n.Min != nil && n.Max != nil {
+			return fmt.Sprintf("%s BETWEEN %s AND %s", column, bound(n.Min), bound(n.Max)), nil
+		}
 	}
-}
 
-func basicWrap(op expr.Operator) RenderFN {
-	return func(left, right string) (string, error) {
-		return fmt.Sprintf("%s(%s)", op, left), nil
+	lower, upper := ">", "<"
+	if n.Inclusive {
+		lower, upper = ">=", "<="
 	}
-}
 
-func toInts(rawMin, rawMax string) (iMin, iMax int, err error) {
-	iMin, err = strconv.Atoi(rawMin)
-	if rawMin != "'*'" && err != nil {
-		return 0, 0, err
+	bounds := []string{}
+	if n.Min != nil {
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", column, lower, bound(n.Min)))
 	}
-
-	iMax, err = strconv.Atoi(rawMax)
-	if rawMax != "'*'" && err != nil {
-		return 0, 0, err
+	if n.Max != nil {
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", column, upper, bound(n.Max)))
 	}
-
-	return iMin, iMax, nil
+	return strings.Join(bounds, " AND "), nil
 }
 
-func toFloats(rawMin, rawMax string) (fMin, fMax float64, err error) {
-	fMin, err = strconv.ParseFloat(rawMin, 64)
-	if rawMin != "'*'" && err != nil {
-		return 0, 0, err
+func basicCompound(op expr.Operator) RenderFN {
+	return func(n Node) (string, error) {
+		return fmt.Sprintf("%s %s %s", n.Left, op, n.Right), nil
 	}
+}
 
-	fMax, err = strconv.ParseFloat(rawMax, 64)
-	if rawMax != "'*'" && err != nil {
-		return 0, 0, err
+func basicWrap(op expr.Operator) RenderFN {
+	return func(n Node) (string, error) {
+		return fmt.Sprintf("%s(%s)", op, n.Left), nil
 	}
-
-	return fMin, fMax, nil
 }
@@ -0,0 +1,141 @@
+package driverclick
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// TextStrategy is how the terms and phrases on the text column, the _source field, are matched
+type TextStrategy int
+
+const (
+	// TextLike matches the values that contain the term ignoring the case with
+	// lowerUTF8(_source) like lowerUTF8('%term%'), it can't use the skip indexes. The %, _ and
+	// backslashes of the term are escaped so they match themselves.
+	TextLike TextStrategy = iota
+	// TextTokens matches the tokens of the terms and phrases with hasTokenCaseInsensitive so the
+	// tokenbf_v1 and ngrambf_v1 skip indexes can be used. A phrase also has to be found as it is
+	// with LIKE and the terms of an OR are searched at once with multiSearchAnyCaseInsensitiveUTF8.
+	// The free text is matched against the text column as well.
+	TextTokens
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// matchText renders the comparison of the text column with a term or phrase
+func matchText(n Node) (string, error) {
+	column, text := n.Columns.String, n.Value.String()
+	if n.Text != TextTokens {
+		// magic for converting into 'some text' -> '%some text%' for propper searching with like
+		return fmt.Sprintf("lowerUTF8(%s) like lowerUTF8(%s)", column, n.Quote("%"+likeEscaper.Replace(text)+"%")), nil
+	}
+
+	tokens := n.Tokens
+	if tokens == nil {
+		tokens = tokenize(text)
+	}
+	if len(tokens) == 1 && tokens[0] == text {
+		return fmt.Sprintf("hasTokenCaseInsensitive(%s, %s)", column, n.Quote(text)), nil
+	}
+
+	// the tokens narrow down the granules with the skip index and the LIKE checks they are in order
+	conditions := []string{}
+	for _, token := range tokens {
+		conditions = append(conditions, fmt.Sprintf("hasTokenCaseInsensitive(%s, %s)", column, n.Quote(token)))
+	}
+	conditions = append(conditions, fmt.Sprintf("lowerUTF8(%s) like lowerUTF8(%s)", column, n.Quote("%"+likeEscaper.Replace(text)+"%")))
+	return strings.Join(conditions, " AND "), nil
+}
+
+// searchText renders the search of any of the values in the text column
+func searchText(n Node) (string, error) {
+	needles := []string{}
+	for _, v := range n.Values {
+		needles = append(needles, n.Quote(v.String()))
+	}
+	return fmt.Sprintf("multiSearchAnyCaseInsensitiveUTF8(%s, [%s])", n.Columns.String, strings.Join(needles, ", ")), nil
+}
+
+// analyzer returns the analyzer of the field, nil when there is none
+func (b Base) analyzer(field string) *analysis.Analyzer {
+	if b.Analyzers == nil {
+		return nil
+	}
+	return b.Analyzers.For(field)
+}
+
+// tokensOf splits a text with the tokenizer of the analyzer into the lower cased tokens hasToken
+// looks for. The filters are left out since the text column holds the text as it is, a stemmed or
+// dropped token would never be found in the rows the LIKE next to it matches.
+func tokensOf(a *analysis.Analyzer, text string) []string {
+	tokenizer := a.Tokenizer
+	if tokenizer == nil {
+		tokenizer = analysis.StandardTokenizer
+	}
+
+	tokens := []string{}
+	for _, t := range tokenizer(text) {
+		tokens = append(tokens, tokenize(strings.ToLower(t.Term))...)
+	}
+	return tokens
+}
+
+// tokenize splits a text into the tokens hasToken looks for
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r < 0x80 && !isToken(string(r))
+	})
+}
+
+// textTerms returns the terms and phrases of an OR that only matches the text column
+func (b Base) textTerms(e *expr.Expression) ([]Value, bool) {
+	if e == nil {
+		return nil, false
+	}
+
+	switch e.Op {
+	case expr.Or:
+		left, ok := b.textTerms(asExpr(e.Left))
+		if !ok {
+			return nil, false
+		}
+		right, ok := b.textTerms(asExpr(e.Right))
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	case expr.Literal:
+		if _, isColumn := e.Left.(expr.Column); isColumn {
+			return nil, false
+		}
+		v, err := valueOf(e)
+		return []Value{v}, err == nil && v.Kind != Bool
+	case expr.Equals:
+		if field, err := fieldOf(e); err != nil || field != "_source" {
+			return nil, false
+		}
+		v, err := valueOf(e.Right)
+		return []Value{v}, err == nil && (v.Kind == String || v.IsNumber())
+	default:
+		return nil, false
+	}
+}
+
+// isFreeText returns true for a term, phrase, wildcard or regexp that isn't on a field
+func isFreeText(e *expr.Expression) bool {
+	switch e.Op {
+	case expr.Literal, expr.Wild, expr.Regexp:
+		_, isColumn := e.Left.(expr.Column)
+		return !isColumn
+	default:
+		return false
+	}
+}
+
+func asExpr(in any) *expr.Expression {
+	e, _ := in.(*expr.Expression)
+	return e
+}
@@ -0,0 +1,550 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+type compileConfig struct {
+	tag string
+}
+
+// CompileOpt configures Compile
+type CompileOpt func(*compileConfig)
+
+// WithTag changes the struct tag used to name fields. It defaults to lucene, e.g. `lucene:"status"`.
+func WithTag(tag string) CompileOpt {
+	return func(c *compileConfig) {
+		c.tag = tag
+	}
+}
+
+// Compile turns the expression into a predicate over T, which must be a struct or a pointer to a
+// struct. Field names are resolved once, either through the `lucene:"name"` struct tag or the (case
+// insensitive) Go field name, and nested structs are addressed with dotted paths. Regexps and
+// wildcards are compiled and literals are converted to the type of their field up front so the
+// returned predicate doesn't use reflection. It follows the same semantics as Match.
+//
+// Supported field types are strings, booleans, integers, floats, slices of strings and pointers to
+// any of them. Unknown fields and unsupported types are reported as errors.
+func Compile[T any](e *expr.Expression, opts ...CompileOpt) (func(T) bool, error) {
+	cfg := &compileConfig{tag: "lucene"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	isPtr := typ.Kind() == reflect.Ptr
+	if isPtr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to compile a predicate for %s, it must be a struct", typ)
+	}
+
+	c := &compiler{cfg: cfg, typ: typ}
+	pred, err := c.compile(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPtr {
+		return func(t T) bool {
+			p := *(*unsafe.Pointer)(unsafe.Pointer(&t))
+			return p != nil && pred(p)
+		}, nil
+	}
+	return func(t T) bool {
+		return pred(unsafe.Pointer(&t))
+	}, nil
+}
+
+// predicate tests the struct the pointer points to
+type predicate func(p unsafe.Pointer) bool
+
+type compiler struct {
+	cfg *compileConfig
+	typ reflect.Type
+}
+
+func (c *compiler) compile(e *expr.Expression) (predicate, error) {
+	if e == nil {
+		return func(unsafe.Pointer) bool { return true }, nil
+	}
+
+	switch e.Op {
+	case expr.And, expr.Or:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return nil, err
+		}
+		l, err := c.compile(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := c.compile(right)
+		if err != nil {
+			return nil, err
+		}
+		if e.Op == expr.And {
+			return func(p unsafe.Pointer) bool { return l(p) && r(p) }, nil
+		}
+		return func(p unsafe.Pointer) bool { return l(p) || r(p) }, nil
+	case expr.Not, expr.MustNot:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		s, err := c.compile(sub)
+		if err != nil {
+			return nil, err
+		}
+		return func(p unsafe.Pointer) bool { return !s(p) }, nil
+	case expr.Must, expr.Boost:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		return c.compile(sub)
+	case expr.Bool:
+		clauses, err := clausesOf(e)
+		if err != nil {
+			return nil, err
+		}
+		preds := make([]predicate, len(clauses))
+		for i, clause := range clauses {
+			preds[i], err = c.compile(clause.Expr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return func(p unsafe.Pointer) bool {
+			matched := make([]bool, len(preds))
+			for i, pred := range preds {
+				matched[i] = pred(p)
+			}
+			return boolMatched(e, clauses, matched)
+		}, nil
+	default:
+		return c.compileLeaf(e)
+	}
+}
+
+func (c *compiler) compileLeaf(e *expr.Expression) (predicate, error) {
+	// reuse the leaf parsing of Match to know what field and test the leaf has. The field tests
+	// built below are specialized for the Go type of the field instead of boxing values.
+	l, err := compileLeaf(e)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []field{}
+	switch {
+	case l.field == "":
+		fields = c.textFields(c.typ, nil)
+	default:
+		f, found, err := c.resolve(l.field)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			fields = []field{f}
+		} else if l.field == SourceField {
+			fields = c.textFields(c.typ, nil)
+		} else {
+			return nil, fmt.Errorf("unknown field %q in %s", l.field, c.typ)
+		}
+	}
+
+	tests := []predicate{}
+	for _, f := range fields {
+		t, err := fieldTest(e, f)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, t)
+	}
+
+	if len(tests) == 1 {
+		return tests[0], nil
+	}
+	return func(p unsafe.Pointer) bool {
+		for _, t := range tests {
+			if t(p) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// field is a resolved struct field. loc returns a pointer to the field value or nil if a pointer
+// on the way to it is nil.
+type field struct {
+	name string
+	typ  reflect.Type
+	loc  func(p unsafe.Pointer) unsafe.Pointer
+}
+
+// resolve finds the struct field for a dotted path.
+func (c *compiler) resolve(path string) (f field, found bool, err error) {
+	typ := c.typ
+	loc := func(p unsafe.Pointer) unsafe.Pointer { return p }
+
+	parts := strings.Split(path, ".")
+	for i := 0; i < len(parts); i++ {
+		sf, consumed, ok := c.lookupField(typ, parts[i:])
+		if !ok {
+			return f, false, nil
+		}
+		i += consumed - 1
+
+		loc = offset(loc, sf.Offset)
+		typ = sf.Type
+		if typ.Kind() == reflect.Ptr {
+			loc = deref(loc)
+			typ = typ.Elem()
+		}
+
+		if i < len(parts)-1 && typ.Kind() != reflect.Struct {
+			return f, false, nil
+		}
+	}
+
+	if !supported(typ) {
+		return f, false, fmt.Errorf("field %q has unsupported type %s", path, typ)
+	}
+	return field{name: path, typ: typ, loc: loc}, true, nil
+}
+
+// lookupField finds the struct field named by the longest prefix of parts so tags containing dots
+// work. It returns how many parts were consumed.
+func (c *compiler) lookupField(typ reflect.Type, parts []string) (sf reflect.StructField, consumed int, ok bool) {
+	for n := len(parts); n > 0; n-- {
+		name := strings.Join(parts[:n], ".")
+		for i := 0; i < typ.NumField(); i++ {
+			candidate := typ.Field(i)
+			if !candidate.IsExported() {
+				continue
+			}
+			tag, hasTag := candidate.Tag.Lookup(c.cfg.tag)
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if (hasTag && tag != "" && tag == name) || ((!hasTag || tag == "") && strings.EqualFold(candidate.Name, name)) {
+				return candidate, n, true
+			}
+		}
+	}
+	return sf, 0, false
+}
+
+// textFields collects every string field of the struct for full text search.
+func (c *compiler) textFields(typ reflect.Type, loc func(p unsafe.Pointer) unsafe.Pointer) []field {
+	if loc == nil {
+		loc = func(p unsafe.Pointer) unsafe.Pointer { return p }
+	}
+
+	out := []field{}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() || sf.Tag.Get(c.cfg.tag) == "-" {
+			continue
+		}
+
+		fl := offset(loc, sf.Offset)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			fl = deref(fl)
+			ft = ft.Elem()
+		}
+
+		switch {
+		case ft.Kind() == reflect.Struct:
+			out = append(out, c.textFields(ft, fl)...)
+		case ft.Kind() == reflect.String, ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String:
+			out = append(out, field{name: sf.Name, typ: ft, loc: fl})
+		}
+	}
+	return out
+}
+
+func offset(loc func(p unsafe.Pointer) unsafe.Pointer, off uintptr) func(p unsafe.Pointer) unsafe.Pointer {
+	return func(p unsafe.Pointer) unsafe.Pointer {
+		base := loc(p)
+		if base == nil {
+			return nil
+		}
+		return unsafe.Pointer(uintptr(base) + off)
+	}
+}
+
+func deref(loc func(p unsafe.Pointer) unsafe.Pointer) func(p unsafe.Pointer) unsafe.Pointer {
+	return func(p unsafe.Pointer) unsafe.Pointer {
+		ptr := loc(p)
+		if ptr == nil {
+			return nil
+		}
+		return *(*unsafe.Pointer)(ptr)
+	}
+}
+
+func supported(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return typ.Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// fieldTest builds the test of a leaf for a field, specialized for the type of the field.
+func fieldTest(e *expr.Expression, f field) (predicate, error) {
+	switch f.typ.Kind() {
+	case reflect.String:
+		test, err := stringTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(p unsafe.Pointer) bool {
+			ptr := f.loc(p)
+			return ptr != nil && test(*(*string)(ptr))
+		}, nil
+	case reflect.Slice:
+		test, err := stringTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(p unsafe.Pointer) bool {
+			ptr := f.loc(p)
+			if ptr == nil {
+				return false
+			}
+			for _, s := range *(*[]string)(ptr) {
+				if test(s) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case reflect.Bool:
+		test, err := boolTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(p unsafe.Pointer) bool {
+			ptr := f.loc(p)
+			return ptr != nil && test(*(*bool)(ptr))
+		}, nil
+	default:
+		load := numberLoader(f.typ.Kind())
+		test, err := numberTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(p unsafe.Pointer) bool {
+			ptr := f.loc(p)
+			return ptr != nil && test(load(ptr))
+		}, nil
+	}
+}
+
+type number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+func loadNumber[N number](p unsafe.Pointer) float64 {
+	return float64(*(*N)(p))
+}
+
+// numberLoader reads a numeric field as a float64.
+func numberLoader(kind reflect.Kind) func(p unsafe.Pointer) float64 {
+	switch kind {
+	case reflect.Int:
+		return loadNumber[int]
+	case reflect.Int8:
+		return loadNumber[int8]
+	case reflect.Int16:
+		return loadNumber[int16]
+	case reflect.Int32:
+		return loadNumber[int32]
+	case reflect.Int64:
+		return loadNumber[int64]
+	case reflect.Uint:
+		return loadNumber[uint]
+	case reflect.Uint8:
+		return loadNumber[uint8]
+	case reflect.Uint16:
+		return loadNumber[uint16]
+	case reflect.Uint32:
+		return loadNumber[uint32]
+	case reflect.Uint64:
+		return loadNumber[uint64]
+	case reflect.Float32:
+		return loadNumber[float32]
+	default:
+		return loadNumber[float64]
+	}
+}
+
+// leafParts splits a leaf expression into its operator and the literal on the right. FUZZY is
+// unwrapped into the term it applies to.
+func leafParts(e *expr.Expression) (op expr.Operator, right *expr.Expression, err error) {
+	switch e.Op {
+	case expr.Literal, expr.Wild, expr.Regexp:
+		if e.Op == expr.Literal {
+			return expr.Equals, e, nil
+		}
+		return expr.Like, e, nil
+	case expr.Range:
+		return e.Op, nil, nil
+	case expr.Fuzzy:
+		sub, err := tree.Expr(e.Left)
+		if err != nil {
+			return op, nil, err
+		}
+		if sub.Op == expr.Equals {
+			sub, err = tree.Expr(sub.Right)
+			if err != nil {
+				return op, nil, err
+			}
+		}
+		return expr.Fuzzy, sub, nil
+	default:
+		right, err = tree.Expr(e.Right)
+		if err != nil {
+			return op, nil, err
+		}
+		if e.Op == expr.Equals && (right.Op == expr.Wild || right.Op == expr.Regexp) {
+			return expr.Like, right, nil
+		}
+		return e.Op, right, nil
+	}
+}
+
+// stringTest builds a test over string values with the literals converted up front.
+func stringTest(e *expr.Expression) (func(string) bool, error) {
+	op, right, err := leafParts(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case expr.Equals:
+		// numbers and booleans parse the value like Match does, strings are substrings
+		test := equalsTest(right.Left)
+		return func(s string) bool { return test(s) }, nil
+	case expr.Like:
+		re, err := likeRegexp(right)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case expr.In:
+		set := map[string]struct{}{}
+		for _, v := range listOf(right) {
+			set[toString(v.Left)] = struct{}{}
+		}
+		return func(s string) bool {
+			_, found := set[s]
+			return found
+		}, nil
+	case expr.Fuzzy:
+		term, distance := strings.ToLower(toString(right.Left)), e.FuzzyDistance()
+		return func(s string) bool { return fuzzyMatch(term, distance, s) }, nil
+	case expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		o, err := orderOf(e)
+		if err != nil {
+			return nil, err
+		}
+		return o.str, nil
+	default:
+		return nil, fmt.Errorf("unable to compile operator [%s]", op)
+	}
+}
+
+// numberTest builds a test over numeric values with the literals converted up front.
+func numberTest(e *expr.Expression) (func(float64) bool, error) {
+	op, right, err := leafParts(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case expr.Equals:
+		if want, ok := toNumber(right.Left); ok && isNumber(right.Left) {
+			return func(n float64) bool { return n == want }, nil
+		}
+		// a string literal on a number field is matched against the formatted number
+		test, err := stringTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(n float64) bool { return test(formatNumber(n)) }, nil
+	case expr.In:
+		set := map[float64]struct{}{}
+		for _, v := range listOf(right) {
+			if n, ok := toNumber(v.Left); ok {
+				set[n] = struct{}{}
+			}
+		}
+		return func(n float64) bool {
+			_, found := set[n]
+			return found
+		}, nil
+	case expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		o, err := orderOf(e)
+		if err != nil {
+			return nil, err
+		}
+		return o.num, nil
+	default:
+		test, err := stringTest(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(n float64) bool { return test(formatNumber(n)) }, nil
+	}
+}
+
+func boolTest(e *expr.Expression) (func(bool) bool, error) {
+	op, right, err := leafParts(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == expr.Equals {
+		if want, ok := toBool(right.Left); ok {
+			return func(b bool) bool { return b == want }, nil
+		}
+	}
+
+	test, err := stringTest(e)
+	if err != nil {
+		return nil, err
+	}
+	return func(b bool) bool { return test(strconv.FormatBool(b)) }, nil
+}
+
+func listOf(e *expr.Expression) []*expr.Expression {
+	vals, _ := e.Left.([]*expr.Expression)
+	return vals
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
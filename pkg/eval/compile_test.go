@@ -0,0 +1,172 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+)
+
+type testRequest struct {
+	Method string `lucene:"method"`
+	Path   string `lucene:"path"`
+}
+
+type testEvent struct {
+	Status  int            `lucene:"status"`
+	Level   string         `lucene:"level"`
+	Message string         `lucene:"message"`
+	Service string         // matched by its case insensitive Go name
+	Latency float64        `lucene:"latency"`
+	Retry   bool           `lucene:"retry"`
+	Tags    []string       `lucene:"tags"`
+	HTTP    testRequest    `lucene:"http"`
+	Parent  *testRequest   `lucene:"parent"`
+	Pod     string         `lucene:"k8s.pod"`
+	Secret  string         `lucene:"-"`
+	Extra   map[string]int `lucene:"extra"`
+}
+
+func TestCompile(t *testing.T) {
+	event := testEvent{
+		Status:  200,
+		Level:   "Error",
+		Message: "connection refused by upstream",
+		Service: "checkout",
+		Latency: 12.5,
+		Retry:   true,
+		Tags:    []string{"prod", "eu-west"},
+		HTTP:    testRequest{Method: "GET", Path: "/api/v1/orders"},
+		Pod:     "checkout-5d9f",
+		Secret:  "hunter2",
+	}
+
+	type tc struct {
+		input string
+		want  bool
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"equal_substring":   {input: "message:refused", want: true},
+		"equal_case":        {input: "level:error", want: true},
+		"equal_number":      {input: "status:200", want: true},
+		"equal_number_miss": {input: "status:201", want: false},
+		"equal_float":       {input: "latency:12.5", want: true},
+		"equal_bool":        {input: "retry:true", want: true},
+		"go_field_name":     {input: "service:checkout", want: true},
+		"nested_struct":     {input: "http.method:get", want: true},
+		"nil_pointer":       {input: "parent.method:get", want: false},
+		"dotted_tag":        {input: "k8s.pod:checkout", want: true},
+		"string_slice":      {input: "tags:eu", want: true},
+		"wildcard":          {input: "service:check*", want: true},
+		"regexp":            {input: "http.path:/.*orders/", want: true},
+		"regexp_anchored":   {input: "http.path:/orders/", want: false},
+		"range":             {input: "status:[200 TO 299]", want: true},
+		"range_exclusive":   {input: "status:{200 TO 299}", want: false},
+		"compare":           {input: "latency:>12", want: true},
+		"compare_strings":   {input: "service:>c", want: true},
+		"in":                {input: "service:(cart OR checkout)", want: true},
+		"in_numbers":        {input: "status:(200 OR 204)", want: true},
+		"fuzzy":             {input: "service:chekcout~", want: true},
+		"boolean_logic":     {input: "(status:500 OR level:error) AND NOT -retry:true", want: true},
+		"boolean_query":     {input: "+service:checkout status:500 -level:info", want: true},
+		"boolean_no_should": {input: "service:cart status:500", want: false},
+		"full_text":         {input: "upstream", want: true},
+		"ignored_field":     {input: "hunter2", want: false},
+		"unknown_field":     {input: "nope:foo", err: `unknown field "nope"`},
+		"unsupported_type":  {input: "extra:foo", err: "unsupported type"},
+		"invalid_regexp":    {input: "message:/a(b/", err: "invalid pattern"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pred, err := Compile[testEvent](e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error compiling expression: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+
+			if got := pred(event); got != tc.want {
+				t.Fatalf("\nwant %v\ngot  %v\nparsed expression: %#v\n", tc.want, got, e)
+			}
+
+			ptrPred, err := Compile[*testEvent](e)
+			if err != nil {
+				t.Fatalf("unexpected error compiling expression for a pointer: %v", err)
+			}
+			if got := ptrPred(&event); got != tc.want {
+				t.Fatalf("\nwant %v for a pointer\ngot  %v\nparsed expression: %#v\n", tc.want, got, e)
+			}
+			if ptrPred(nil) {
+				t.Fatalf("expected a nil pointer to never match")
+			}
+		})
+	}
+}
+
+func TestCompileAgreesWithMatch(t *testing.T) {
+	type stringStatus struct {
+		Status string `lucene:"status"`
+	}
+
+	for _, input := range []string{"status:200", "status:1200", "status:120", "status:true"} {
+		t.Run(input, func(t *testing.T) {
+			e, err := lucene.Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pred, err := Compile[stringStatus](e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := Match(e, map[string]any{"status": "1200"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pred(stringStatus{Status: "1200"}); got != want {
+				t.Fatalf("\nwant %v like Match\ngot  %v\nparsed expression: %#v\n", want, got, e)
+			}
+		})
+	}
+}
+
+func TestCompileNotAStruct(t *testing.T) {
+	e, err := lucene.Parse("a:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Compile[map[string]any](e)
+	if err == nil {
+		t.Fatalf("expected an error compiling a predicate for a map")
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	e, err := lucene.Parse("(status:[200 TO 299] OR level:error) AND service:check* AND NOT tags:staging")
+	if err != nil {
+		b.Fatal(err)
+	}
+	pred, err := Compile[*testEvent](e)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	event := &testEvent{Status: 200, Level: "info", Service: "checkout", Tags: []string{"prod"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pred(event)
+	}
+}
@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// SourceField is the magic full text column. Just like in driverclick an equality on it is a case
+// insensitive substring search. If the document has no _source value every string in the document
+// is searched instead.
+const SourceField = "_source"
+
+// Match checks whether the parsed expression matches a JSON like document. Nested maps are
+// addressed with dotted paths (e.g. http.status) and slices match if any of their elements match.
+//
+// The semantics follow driverclick: string equality is a case insensitive substring match (an empty
+// string must match exactly), numbers compare numerically, wildcards and regexps are case
+// insensitive and match the whole value and IN lists match exactly. Unlike the SQL
+// output a missing field never matches, it is not treated as a zero value.
+func Match(e *expr.Expression, doc map[string]any) (bool, error) {
+	if e == nil {
+		return true, nil
+	}
+	return match(e, doc)
+}
+
+func match(e *expr.Expression, doc map[string]any) (bool, error) {
+	switch e.Op {
+	case expr.And:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return false, err
+		}
+		matched, err := match(left, doc)
+		if err != nil || !matched {
+			return false, err
+		}
+		return match(right, doc)
+	case expr.Or:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return false, err
+		}
+		matched, err := match(left, doc)
+		if err != nil || matched {
+			return matched, err
+		}
+		return match(right, doc)
+	case expr.Not, expr.MustNot:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return false, err
+		}
+		matched, err := match(sub, doc)
+		return !matched, err
+	case expr.Must, expr.Boost:
+		// must is a plain requirement when filtering and boosts only affect scoring
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return false, err
+		}
+		return match(sub, doc)
+	case expr.Bool:
+		clauses, err := clausesOf(e)
+		if err != nil {
+			return false, err
+		}
+		matched := make([]bool, len(clauses))
+		for i, c := range clauses {
+			matched[i], err = match(c.Expr, doc)
+			if err != nil {
+				return false, err
+			}
+		}
+		return boolMatched(e, clauses, matched), nil
+	default:
+		l, err := compileLeaf(e)
+		if err != nil {
+			return false, err
+		}
+		return l.match(doc), nil
+	}
+}
+
+func clausesOf(e *expr.Expression) ([]*expr.BooleanClause, error) {
+	clauses := e.Clauses()
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("%s must have clauses, not %T", e.Op, e.Left)
+	}
+	for _, c := range clauses {
+		if c == nil || c.Expr == nil {
+			return nil, fmt.Errorf("%s must not have empty clauses", e.Op)
+		}
+	}
+	return clauses, nil
+}
+
+// boolMatched combines the outcome of the clauses of a BOOL expression: every MUST clause has to
+// match, no MUST_NOT clause may match and at least the required number of SHOULD clauses has to.
+func boolMatched(e *expr.Expression, clauses []*expr.BooleanClause, matched []bool) bool {
+	shoulds := 0
+	for i, c := range clauses {
+		switch c.Occur {
+		case expr.OccurMust:
+			if !matched[i] {
+				return false
+			}
+		case expr.OccurMustNot:
+			if matched[i] {
+				return false
+			}
+		default:
+			if matched[i] {
+				shoulds++
+			}
+		}
+	}
+	return shoulds >= e.RequiredShould()
+}
@@ -0,0 +1,279 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+var testDoc = map[string]any{
+	"status":  200,
+	"level":   "Error",
+	"message": "connection refused by upstream",
+	"service": "checkout",
+	"latency": 12.5,
+	"retry":   true,
+	"empty":   "",
+	"tags":    []any{"prod", "eu-west"},
+	"http": map[string]any{
+		"method": "GET",
+		"path":   "/api/v1/orders",
+	},
+	"k8s.pod": "checkout-5d9f",
+}
+
+func TestMatch(t *testing.T) {
+	type tc struct {
+		input string
+		want  bool
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"equal_substring": {
+			input: "message:refused",
+			want:  true,
+		},
+		"equal_case_insensitive": {
+			input: "level:error",
+			want:  true,
+		},
+		"equal_miss": {
+			input: "level:warn",
+			want:  false,
+		},
+		"equal_number": {
+			input: "status:200",
+			want:  true,
+		},
+		"equal_float": {
+			input: "latency:12.5",
+			want:  true,
+		},
+		"equal_bool": {
+			input: "retry:true",
+			want:  true,
+		},
+		"equal_empty_string": {
+			input: `empty:""`,
+			want:  true,
+		},
+		"missing_field": {
+			input: "nope:foo",
+			want:  false,
+		},
+		"nested_path": {
+			input: "http.method:get",
+			want:  true,
+		},
+		"dotted_key": {
+			input: "k8s.pod:checkout",
+			want:  true,
+		},
+		"multi_valued": {
+			input: "tags:eu",
+			want:  true,
+		},
+		"wildcard": {
+			input: "service:check*",
+			want:  true,
+		},
+		"wildcard_is_anchored": {
+			input: "service:heck*",
+			want:  false,
+		},
+		"wildcard_single_char": {
+			input: "service:checkou?",
+			want:  true,
+		},
+		"regexp": {
+			input: "http.path:/.*orders/",
+			want:  true,
+		},
+		"regexp_anchored": {
+			input: "http.path:/orders/",
+			want:  false,
+		},
+		"regexp_lucene_syntax": {
+			input: `http.path:/.*v<0-3>\/orders/`,
+			want:  true,
+		},
+		"regexp_miss": {
+			input: "http.path:/^orders/",
+			want:  false,
+		},
+		"invalid_regexp": {
+			input: "http.path:/a(b/",
+			err:   "invalid pattern",
+		},
+		"range_inclusive": {
+			input: "status:[200 TO 299]",
+			want:  true,
+		},
+		"range_exclusive": {
+			input: "status:{200 TO 299}",
+			want:  false,
+		},
+		"range_unbound": {
+			input: "latency:[10 TO *]",
+			want:  true,
+		},
+		"range_over_strings": {
+			input: "service:[a TO d]",
+			want:  true,
+		},
+		"greater": {
+			input: "status:>199",
+			want:  true,
+		},
+		"less_eq": {
+			input: "latency:<=12",
+			want:  false,
+		},
+		"in_list": {
+			input: "service:(cart OR checkout)",
+			want:  true,
+		},
+		"in_list_is_exact": {
+			input: "service:(check OR cart)",
+			want:  false,
+		},
+		"and": {
+			input: "status:200 AND level:error",
+			want:  true,
+		},
+		"or": {
+			input: "status:500 OR level:error",
+			want:  true,
+		},
+		"not": {
+			input: "NOT level:error",
+			want:  false,
+		},
+		"must_not": {
+			input: "-level:warn",
+			want:  true,
+		},
+		"must": {
+			input: "+level:error",
+			want:  true,
+		},
+		"boolean_should_is_optional": {
+			input: "+level:error service:cart -status:500",
+			want:  true,
+		},
+		"boolean_must_not": {
+			input: "+level:error -status:200",
+			want:  false,
+		},
+		"boolean_one_should_without_must": {
+			input: "service:cart status:200 -retry:false",
+			want:  true,
+		},
+		"boolean_no_should_without_must": {
+			input: "service:cart status:500 -retry:false",
+			want:  false,
+		},
+		"fuzzy": {
+			input: "service:chekcout~1",
+			want:  true,
+		},
+		"fuzzy_token": {
+			input: "message:upstraem~",
+			want:  true,
+		},
+		"fuzzy_too_far": {
+			input: "service:chkcut~1",
+			want:  false,
+		},
+		"boost": {
+			input: "level:error^2",
+			want:  true,
+		},
+		"full_text_literal": {
+			input: "refused",
+			want:  true,
+		},
+		"source_without_source_column": {
+			input: "_source:upstream",
+			want:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Match(e, testDoc)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error evaluating expression: %v", err)
+			}
+
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("\nwant %v\ngot  %v\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestMatchSourceColumn(t *testing.T) {
+	doc := map[string]any{
+		"_source": "GET /health 200",
+		"message": "refused",
+	}
+
+	e, err := lucene.Parse("refused", lucene.WithDefaultField(SourceField))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Match(e, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got {
+		t.Fatalf("expected _source to be searched instead of the whole document")
+	}
+
+	got, err = Match(expr.Eq(SourceField, "health"), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got {
+		t.Fatalf("expected _source to match")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tcs := map[string]struct {
+		a, b string
+		want int
+	}{
+		"equal":         {"kitten", "kitten", 0},
+		"substitution":  {"kitten", "sitten", 1},
+		"classic":       {"kitten", "sitting", 3},
+		"transposition": {"abcd", "acbd", 1},
+		"empty":         {"", "abc", 3},
+		"unicode":       {"café", "cafe", 1},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			if got := EditDistance(tc.a, tc.b); got != tc.want {
+				t.Fatalf("EditDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
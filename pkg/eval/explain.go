@@ -0,0 +1,297 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// Reason describes why a leaf of the expression did or did not match
+type Reason string
+
+// reasons a leaf can have
+const (
+	ReasonMatched      Reason = "matched"
+	ReasonMissingField Reason = "missing field"
+	ReasonTypeMismatch Reason = "type mismatch"
+	ReasonNoMatch      Reason = "value does not match"
+	ReasonWildcardMiss Reason = "wildcard does not match"
+	ReasonRegexpMiss   Reason = "regexp does not match"
+	ReasonOutOfRange   Reason = "out of range"
+	ReasonNotInList    Reason = "not in list"
+	ReasonTooFar       Reason = "edit distance too large"
+)
+
+// Explanation mirrors the expression tree with the outcome of every node. Leaves also carry the
+// field and the value that was looked up in the document and the reason for their outcome.
+type Explanation struct {
+	Expression string         `json:"expression"`
+	Operator   string         `json:"operator"`
+	Matched    bool           `json:"matched"`
+	Field      string         `json:"field,omitempty"`
+	Value      any            `json:"value,omitempty"`
+	Reason     Reason         `json:"reason,omitempty"`
+	Children   []*Explanation `json:"children,omitempty"`
+}
+
+// Explain evaluates the expression against the document like Match does and explains the outcome
+// of every node. Unlike Match it does not short circuit so every clause is explained.
+func Explain(e *expr.Expression, doc map[string]any) (*Explanation, error) {
+	if e == nil {
+		return &Explanation{Matched: true}, nil
+	}
+	return explain(e, doc)
+}
+
+// String renders the explanation as an indented tree.
+func (x *Explanation) String() string {
+	var sb strings.Builder
+	x.write(&sb, 0)
+	return sb.String()
+}
+
+func (x *Explanation) write(sb *strings.Builder, depth int) {
+	mark := "[ ]"
+	if x.Matched {
+		mark = "[x]"
+	}
+	sb.WriteString(fmt.Sprintf("%s%s %s", strings.Repeat("  ", depth), mark, x.Expression))
+
+	if x.Reason != "" {
+		sb.WriteString(" - " + string(x.Reason))
+		if x.Field != "" && x.Reason != ReasonMissingField {
+			sb.WriteString(fmt.Sprintf(" (%s = %#v)", x.Field, x.Value))
+		}
+	}
+	sb.WriteString("\n")
+
+	for _, c := range x.Children {
+		c.write(sb, depth+1)
+	}
+}
+
+func explain(e *expr.Expression, doc map[string]any) (*Explanation, error) {
+	x := &Explanation{
+		Expression: expression(e),
+		Operator:   e.Op.String(),
+	}
+
+	switch e.Op {
+	case expr.And, expr.Or:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return nil, err
+		}
+		l, err := explain(left, doc)
+		if err != nil {
+			return nil, err
+		}
+		r, err := explain(right, doc)
+		if err != nil {
+			return nil, err
+		}
+		x.Children = []*Explanation{l, r}
+		if e.Op == expr.And {
+			x.Matched = l.Matched && r.Matched
+		} else {
+			x.Matched = l.Matched || r.Matched
+		}
+		return x, nil
+	case expr.Not, expr.MustNot, expr.Must, expr.Boost:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		s, err := explain(sub, doc)
+		if err != nil {
+			return nil, err
+		}
+		x.Children = []*Explanation{s}
+		x.Matched = s.Matched
+		if e.Op == expr.Not || e.Op == expr.MustNot {
+			x.Matched = !s.Matched
+		}
+		return x, nil
+	case expr.Bool:
+		clauses, err := clausesOf(e)
+		if err != nil {
+			return nil, err
+		}
+		matched := make([]bool, len(clauses))
+		for i, c := range clauses {
+			sub, err := explain(c.Expr, doc)
+			if err != nil {
+				return nil, err
+			}
+			// wrap every clause so the explanation shows how it occurs
+			cx := &Explanation{
+				Expression: sub.Expression,
+				Operator:   c.Occur.String(),
+				Matched:    sub.Matched != (c.Occur == expr.OccurMustNot),
+				Children:   []*Explanation{sub},
+			}
+			matched[i] = sub.Matched
+			x.Children = append(x.Children, cx)
+		}
+		x.Matched = boolMatched(e, clauses, matched)
+		return x, nil
+	default:
+		l, err := compileLeaf(e)
+		if err != nil {
+			return nil, err
+		}
+		x.Field = l.field
+
+		vals := l.values(doc)
+		if l.field != "" {
+			x.Value = explainedValue(vals)
+		}
+
+		if len(vals) == 0 {
+			x.Reason = ReasonMissingField
+			return x, nil
+		}
+
+		for _, v := range vals {
+			if l.test(v) {
+				x.Matched = true
+				x.Reason = ReasonMatched
+				return x, nil
+			}
+		}
+		x.Reason = mismatch(e, vals)
+		return x, nil
+	}
+}
+
+func explainedValue(vals []any) any {
+	switch len(vals) {
+	case 0:
+		return nil
+	case 1:
+		return vals[0]
+	default:
+		return vals
+	}
+}
+
+// mismatch works out why none of the values matched the leaf.
+func mismatch(e *expr.Expression, vals []any) Reason {
+	op, right, err := leafParts(e)
+	if err != nil {
+		return ReasonNoMatch
+	}
+
+	if !typesMatch(e, op, right, vals) {
+		return ReasonTypeMismatch
+	}
+
+	switch op {
+	case expr.Like:
+		if right.Op == expr.Regexp {
+			return ReasonRegexpMiss
+		}
+		return ReasonWildcardMiss
+	case expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		return ReasonOutOfRange
+	case expr.In:
+		return ReasonNotInList
+	case expr.Fuzzy:
+		return ReasonTooFar
+	default:
+		return ReasonNoMatch
+	}
+}
+
+// typesMatch checks whether any of the values has a type the leaf can compare with.
+func typesMatch(e *expr.Expression, op expr.Operator, right *expr.Expression, vals []any) bool {
+	wants := []any{}
+	switch op {
+	case expr.Range:
+		boundary, _ := e.Right.(*expr.RangeBoundary)
+		if boundary == nil {
+			return true
+		}
+		for _, b := range []any{boundary.Min, boundary.Max} {
+			if v, err := boundOf(b); err == nil && v != nil {
+				wants = append(wants, v)
+			}
+		}
+	case expr.In:
+		for _, v := range listOf(right) {
+			wants = append(wants, v.Left)
+		}
+	case expr.Equals, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		wants = append(wants, right.Left)
+	default:
+		// wildcards, regexps and fuzzy terms match against the string form of anything
+		return true
+	}
+
+	for _, want := range wants {
+		for _, v := range vals {
+			if canCompare(op, want, v) {
+				return true
+			}
+		}
+	}
+	return len(wants) == 0
+}
+
+func canCompare(op expr.Operator, want, got any) bool {
+	switch {
+	case isNumber(want):
+		_, ok := toNumber(got)
+		return ok
+	case op != expr.Equals && op != expr.In:
+		// a string bound can only order strings
+		return !isNumber(got)
+	default:
+		if _, isBool := want.(bool); isBool {
+			_, ok := toBool(got)
+			return ok
+		}
+		// strings are compared with the string form of any value
+		return true
+	}
+}
+
+// expression renders the expression of a node. The AND and OR operands of a different operator
+// are parenthesized so the explanation keeps the grouping of the query.
+func expression(e *expr.Expression) string {
+	switch e.Op {
+	case expr.And, expr.Or:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return e.String()
+		}
+		return fmt.Sprintf("%s %s %s", grouped(e.Op, left), e.Op, grouped(e.Op, right))
+	case expr.Not:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return e.String()
+		}
+		return fmt.Sprintf("%s(%s)", e.Op, expression(sub))
+	case expr.Must, expr.MustNot:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return e.String()
+		}
+		prefix := "+"
+		if e.Op == expr.MustNot {
+			prefix = "-"
+		}
+		return prefix + grouped(e.Op, sub)
+	default:
+		return e.String()
+	}
+}
+
+func grouped(parent expr.Operator, e *expr.Expression) string {
+	if (e.Op == expr.And || e.Op == expr.Or) && e.Op != parent {
+		return "(" + expression(e) + ")"
+	}
+	return expression(e)
+}
@@ -0,0 +1,99 @@
+package eval
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+)
+
+func TestExplain(t *testing.T) {
+	type tc struct {
+		input  string
+		want   bool
+		reason Reason
+	}
+
+	tcs := map[string]tc{
+		"matched":         {input: "level:error", want: true, reason: ReasonMatched},
+		"missing_field":   {input: "nope:foo", want: false, reason: ReasonMissingField},
+		"type_mismatch":   {input: "level:5", want: false, reason: ReasonTypeMismatch},
+		"no_match":        {input: "level:warn", want: false, reason: ReasonNoMatch},
+		"wildcard_miss":   {input: "service:cart*", want: false, reason: ReasonWildcardMiss},
+		"regexp_miss":     {input: "http.path:/^orders/", want: false, reason: ReasonRegexpMiss},
+		"out_of_range":    {input: "status:[300 TO 399]", want: false, reason: ReasonOutOfRange},
+		"compare_miss":    {input: "latency:>100", want: false, reason: ReasonOutOfRange},
+		"string_ordering": {input: "status:[a TO z]", want: false, reason: ReasonTypeMismatch},
+		"not_in_list":     {input: "service:(cart OR basket)", want: false, reason: ReasonNotInList},
+		"too_far":         {input: "service:chkcut~1", want: false, reason: ReasonTooFar},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Explain(e, testDoc)
+			if err != nil {
+				t.Fatalf("unexpected error explaining expression: %v", err)
+			}
+
+			if got.Matched != tc.want {
+				t.Fatalf("expected matched to be %v but got %v:\n%s", tc.want, got.Matched, got)
+			}
+			if got.Reason != tc.reason {
+				t.Fatalf("expected reason [%s] but got [%s]:\n%s", tc.reason, got.Reason, got)
+			}
+		})
+	}
+}
+
+func TestExplainTree(t *testing.T) {
+	e, err := lucene.Parse("status:200 AND (level:warn OR NOT service:checkout)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Explain(e, testDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matched, err := Match(e, testDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Matched != matched {
+		t.Fatalf("explanation says %v but Match says %v", got.Matched, matched)
+	}
+
+	want := strings.Join([]string{
+		"[ ] status:200 AND (level:warn OR NOT(service:checkout))",
+		"  [x] status:200 - matched (status = 200)",
+		"  [ ] level:warn OR NOT(service:checkout)",
+		`    [ ] level:warn - value does not match (level = "Error")`,
+		"    [ ] NOT(service:checkout)",
+		`      [x] service:checkout - matched (service = "checkout")`,
+		"",
+	}, "\n")
+	if got.String() != want {
+		t.Fatalf("\nwant\n%s\ngot\n%s", want, got)
+	}
+
+	raw, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("expected no error during marshal but got [%s]", err)
+	}
+
+	var roundtrip Explanation
+	err = json.Unmarshal(raw, &roundtrip)
+	if err != nil {
+		t.Fatalf("expected no error during unmarshal but got [%s]", err)
+	}
+	if roundtrip.Children[1].Children[0].Reason != ReasonNoMatch {
+		t.Fatalf("expected the json to keep the reason of nested nodes: %s", raw)
+	}
+}
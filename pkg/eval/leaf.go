@@ -0,0 +1,447 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// leaf is a compiled leaf expression. It knows which field to look up and how to test a single
+// value of that field.
+type leaf struct {
+	// field is the looked up field. An empty field searches every value in the document.
+	field string
+	test  func(v any) bool
+}
+
+// match checks whether any value of the field passes the test.
+func (l leaf) match(doc map[string]any) bool {
+	for _, v := range l.values(doc) {
+		if l.test(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// values returns the values of the field in the document.
+func (l leaf) values(doc map[string]any) []any {
+	if l.field == "" {
+		return fullText(doc)
+	}
+
+	val, found := lookup(doc, l.field)
+	if !found && l.field == SourceField {
+		return fullText(doc)
+	}
+	return values(val)
+}
+
+func fullText(doc map[string]any) []any {
+	out := []any{}
+	for _, s := range allStrings(doc) {
+		out = append(out, s)
+	}
+	return out
+}
+
+// compileLeaf builds the test for a leaf expression.
+func compileLeaf(e *expr.Expression) (l leaf, err error) {
+	switch e.Op {
+	case expr.Literal:
+		// a bare literal without a field is a full text search
+		return leaf{test: equalsTest(e.Left)}, nil
+	case expr.Wild, expr.Regexp:
+		test, err := likeTest(e)
+		return leaf{test: test}, err
+	case expr.Equals:
+		field, err := tree.Field(e)
+		if err != nil {
+			return l, err
+		}
+		right, err := tree.Expr(e.Right)
+		if err != nil {
+			return l, err
+		}
+		if right.Op == expr.Wild || right.Op == expr.Regexp {
+			test, err := likeTest(right)
+			return leaf{field: field, test: test}, err
+		}
+		return leaf{field: field, test: equalsTest(right.Left)}, nil
+	case expr.Like:
+		field, err := tree.Field(e)
+		if err != nil {
+			return l, err
+		}
+		right, err := tree.Expr(e.Right)
+		if err != nil {
+			return l, err
+		}
+		test, err := likeTest(right)
+		return leaf{field: field, test: test}, err
+	case expr.In:
+		field, err := tree.Field(e)
+		if err != nil {
+			return l, err
+		}
+		list, err := tree.Expr(e.Right)
+		if err != nil {
+			return l, err
+		}
+		vals, isList := list.Left.([]*expr.Expression)
+		if list.Op != expr.List || !isList {
+			return l, fmt.Errorf("IN must have a list on the right, not %s", list.Op)
+		}
+		return leaf{field: field, test: inTest(vals)}, nil
+	case expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		field, err := tree.Field(e)
+		if err != nil {
+			return l, err
+		}
+		o, err := orderOf(e)
+		return leaf{field: field, test: o.test}, err
+	case expr.Fuzzy:
+		return compileFuzzy(e)
+	default:
+		return l, fmt.Errorf("unable to evaluate operator [%s]", e.Op)
+	}
+}
+
+func compileFuzzy(e *expr.Expression) (l leaf, err error) {
+	sub, err := tree.Expr(e.Left)
+	if err != nil {
+		return l, err
+	}
+
+	term := sub
+	if sub.Op == expr.Equals {
+		l.field, err = tree.Field(sub)
+		if err != nil {
+			return l, err
+		}
+		term, err = tree.Expr(sub.Right)
+		if err != nil {
+			return l, err
+		}
+	}
+
+	if term.Op != expr.Literal {
+		return l, fmt.Errorf("FUZZY must wrap a term, not %s", term.Op)
+	}
+
+	l.test = fuzzyTest(toString(term.Left), e.FuzzyDistance())
+	return l, nil
+}
+
+// equalsTest is a case insensitive substring match for strings, the same as the lowerUTF8(...) like
+// lowerUTF8('%value%') filter driverclick renders. Numbers and booleans compare by value.
+func equalsTest(want any) func(v any) bool {
+	if isNumber(want) {
+		n, _ := toNumber(want)
+		return func(v any) bool {
+			got, ok := toNumber(v)
+			return ok && got == n
+		}
+	}
+
+	if b, isBool := want.(bool); isBool {
+		return func(v any) bool {
+			got, ok := toBool(v)
+			return ok && got == b
+		}
+	}
+
+	s := toString(want)
+	if s == "" {
+		return func(v any) bool {
+			return toString(v) == ""
+		}
+	}
+
+	s = strings.ToLower(s)
+	return func(v any) bool {
+		return strings.Contains(strings.ToLower(toString(v)), s)
+	}
+}
+
+// inTest matches values exactly, the same as the IN (...) filter driverclick renders.
+func inTest(vals []*expr.Expression) func(v any) bool {
+	return func(v any) bool {
+		for _, want := range vals {
+			if sameValue(want.Left, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func sameValue(want, got any) bool {
+	if isNumber(want) {
+		n, _ := toNumber(want)
+		g, ok := toNumber(got)
+		return ok && g == n
+	}
+	if b, isBool := want.(bool); isBool {
+		g, ok := toBool(got)
+		return ok && g == b
+	}
+	return toString(want) == toString(got)
+}
+
+// likeTest compiles a wildcard or regexp into a case insensitive regexp. Both are anchored like the
+// LIKE and match() they render to and regexps follow the lucene syntax.
+func likeTest(e *expr.Expression) (func(v any) bool, error) {
+	re, err := likeRegexp(e)
+	if err != nil {
+		return nil, err
+	}
+	return func(v any) bool {
+		return re.MatchString(toString(v))
+	}, nil
+}
+
+func likeRegexp(e *expr.Expression) (*regexp.Regexp, error) {
+	var pattern string
+	switch e.Op {
+	case expr.Wild:
+		pattern = wildcard.Parse(toString(e.Left)).RE2()
+	case expr.Regexp:
+		translated, err := regex.ToRE2(strings.TrimSuffix(strings.TrimPrefix(toString(e.Left), "/"), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+		}
+		pattern = translated
+	default:
+		return nil, fmt.Errorf("LIKE must have a wildcard or regexp on the right, not %s", e.Op)
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+	}
+	return re, nil
+}
+
+// order is a range or comparison split into checks for numbers and for strings. Numbers are
+// ordered numerically and strings lexicographically. A numeric bound parses string values as
+// numbers and a string bound never matches a number.
+type order struct {
+	num func(n float64) bool
+	str func(s string) bool
+}
+
+func (o order) test(v any) bool {
+	if isNumber(v) {
+		n, _ := toNumber(v)
+		return o.num(n)
+	}
+	return o.str(toString(v))
+}
+
+// orderOf builds the order for a range or comparison expression.
+func orderOf(e *expr.Expression) (o order, err error) {
+	if e.Op != expr.Range {
+		right, err := tree.Expr(e.Right)
+		if err != nil {
+			return o, err
+		}
+		return boundOrder(e.Op, right.Left), nil
+	}
+
+	boundary, isBoundary := e.Right.(*expr.RangeBoundary)
+	if !isBoundary || boundary == nil {
+		return o, fmt.Errorf("RANGE must have a range boundary on the right, not %T", e.Right)
+	}
+	min, err := boundOf(boundary.Min)
+	if err != nil {
+		return o, err
+	}
+	max, err := boundOf(boundary.Max)
+	if err != nil {
+		return o, err
+	}
+
+	lowerOp, upperOp := expr.Greater, expr.Less
+	if boundary.Inclusive {
+		lowerOp, upperOp = expr.GreaterEq, expr.LessEq
+	}
+
+	checks := []order{}
+	if min != nil {
+		checks = append(checks, boundOrder(lowerOp, min))
+	}
+	if max != nil {
+		checks = append(checks, boundOrder(upperOp, max))
+	}
+
+	return order{
+		num: func(n float64) bool {
+			for _, c := range checks {
+				if !c.num(n) {
+					return false
+				}
+			}
+			return true
+		},
+		str: func(s string) bool {
+			for _, c := range checks {
+				if !c.str(s) {
+					return false
+				}
+			}
+			return true
+		},
+	}, nil
+}
+
+// boundOf unwraps a range boundary. The * wildcard is an unbounded side and returned as nil.
+func boundOf(in any) (any, error) {
+	e, err := tree.Expr(in)
+	if err != nil {
+		return nil, err
+	}
+	if e.Op == expr.Wild && e.Left == "*" {
+		return nil, nil
+	}
+	return e.Left, nil
+}
+
+// boundOrder compares values against a single bound.
+func boundOrder(op expr.Operator, want any) order {
+	cmp := func(c int) bool {
+		switch op {
+		case expr.Greater:
+			return c > 0
+		case expr.GreaterEq:
+			return c >= 0
+		case expr.Less:
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}
+
+	if isNumber(want) {
+		n, _ := toNumber(want)
+		num := func(got float64) bool {
+			switch {
+			case got < n:
+				return cmp(-1)
+			case got > n:
+				return cmp(1)
+			default:
+				return cmp(0)
+			}
+		}
+		return order{
+			num: num,
+			str: func(s string) bool {
+				got, ok := toNumber(s)
+				return ok && num(got)
+			},
+		}
+	}
+
+	bound := toString(want)
+	return order{
+		num: func(float64) bool { return false },
+		str: func(s string) bool { return cmp(strings.Compare(s, bound)) },
+	}
+}
+
+// fuzzyTest matches if the whole value or any of its tokens are within the edit distance of the
+// term. The comparison is case insensitive.
+func fuzzyTest(term string, distance int) func(v any) bool {
+	term = strings.ToLower(term)
+	return func(v any) bool {
+		return fuzzyMatch(term, distance, toString(v))
+	}
+}
+
+// fuzzyMatch expects the term to already be lower cased.
+func fuzzyMatch(term string, distance int, s string) bool {
+	s = strings.ToLower(s)
+	if EditDistance(s, term) <= distance {
+		return true
+	}
+	for _, tok := range tokenize(s) {
+		if EditDistance(tok, term) <= distance {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func isNumber(in any) bool {
+	switch in.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// toNumber converts numbers and numeric strings to a float64.
+func toNumber(in any) (float64, bool) {
+	switch v := in.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toBool(in any) (bool, bool) {
+	switch v := in.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
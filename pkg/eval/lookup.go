@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+)
+
+// lookup resolves a field in the document. An exact key wins over a dotted path so documents
+// with flattened keys like "http.status" work as well as nested maps.
+func lookup(doc map[string]any, field string) (val any, found bool) {
+	if doc == nil {
+		return nil, false
+	}
+
+	val, found = doc[field]
+	if found {
+		return val, true
+	}
+
+	// walk into nested maps, trying every dot as a path separator so keys that contain dots
+	// themselves (e.g. doc["a.b"]["c"]) still resolve
+	for i := 0; i < len(field); i++ {
+		if field[i] != '.' {
+			continue
+		}
+		sub, isMap := asMap(doc[field[:i]])
+		if !isMap {
+			continue
+		}
+		val, found = lookup(sub, field[i+1:])
+		if found {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func asMap(in any) (map[string]any, bool) {
+	m, isMap := in.(map[string]any)
+	return m, isMap
+}
+
+// values flattens a looked up value into its scalar values. Slices are multi valued fields.
+func values(in any) []any {
+	switch v := in.(type) {
+	case nil:
+		return nil
+	case []any:
+		out := []any{}
+		for _, item := range v {
+			out = append(out, values(item)...)
+		}
+		return out
+	case []string:
+		out := make([]any, 0, len(v))
+		for _, s := range v {
+			out = append(out, s)
+		}
+		return out
+	default:
+		return []any{v}
+	}
+}
+
+// allStrings collects every scalar value of the document as a string. It is used for full text
+// matching when there is no _source column.
+func allStrings(doc map[string]any) []string {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	// keep the output stable so explanations don't change between runs
+	sort.Strings(keys)
+
+	out := []string{}
+	for _, k := range keys {
+		if sub, isMap := asMap(doc[k]); isMap {
+			out = append(out, allStrings(sub)...)
+			continue
+		}
+		for _, v := range values(doc[k]) {
+			if sub, isMap := asMap(v); isMap {
+				out = append(out, allStrings(sub)...)
+				continue
+			}
+			out = append(out, toString(v))
+		}
+	}
+	return out
+}
+
+func toString(in any) string {
+	switch v := in.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
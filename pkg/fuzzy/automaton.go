@@ -0,0 +1,114 @@
+package fuzzy
+
+// Automaton accepts the strings within a Damerau-Levenshtein distance (optimal string alignment) of
+// a term, counted in runes. A transposition of two adjacent runes is a single edit like in lucene
+// fuzzy queries.
+//
+// The automaton is fed one rune at a time so strings that share a prefix share the work of reading
+// it, and CanMatch stops the walk as soon as no string with the prefix read so far can match. That
+// is what makes intersecting it with a sorted term dictionary cheap.
+type Automaton struct {
+	term     []rune
+	distance int
+}
+
+// State is the state of the automaton after reading a prefix. States are immutable so they can be
+// kept around to continue from a shared prefix.
+type State struct {
+	// row holds the distance of the prefix read so far to every prefix of the term, capped at the
+	// maximum distance plus one
+	row []int
+	// prev is the row before the last rune was read and last is that rune. Both are needed to
+	// detect transpositions.
+	prev []int
+	last rune
+}
+
+// NewAutomaton creates an automaton accepting the strings within distance edits of the term.
+func NewAutomaton(term string, distance int) *Automaton {
+	if distance < 0 {
+		distance = 0
+	}
+	return &Automaton{term: []rune(term), distance: distance}
+}
+
+// Start returns the state before anything is read
+func (a *Automaton) Start() State {
+	row := make([]int, len(a.term)+1)
+	for j := range row {
+		row[j] = a.cap(j)
+	}
+	return State{row: row}
+}
+
+// Step reads a rune and returns the next state
+func (a *Automaton) Step(s State, r rune) State {
+	row := make([]int, len(s.row))
+	row[0] = a.cap(s.row[0] + 1)
+	for j := 1; j < len(row); j++ {
+		cost := 1
+		if a.term[j-1] == r {
+			cost = 0
+		}
+		d := min3(
+			s.row[j]+1,      // deletion
+			row[j-1]+1,      // insertion
+			s.row[j-1]+cost, // substitution
+		)
+		if s.prev != nil && j > 1 && a.term[j-1] == s.last && a.term[j-2] == r && s.prev[j-2]+1 < d {
+			d = s.prev[j-2] + 1 // transposition
+		}
+		row[j] = a.cap(d)
+	}
+	return State{row: row, prev: s.row, last: r}
+}
+
+// IsMatch checks whether the string read so far is within the distance of the term
+func (a *Automaton) IsMatch(s State) bool {
+	return s.row[len(s.row)-1] <= a.distance
+}
+
+// CanMatch checks whether any string starting with what was read so far can still match
+func (a *Automaton) CanMatch(s State) bool {
+	for _, d := range s.row {
+		if d <= a.distance {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the edit distance of the string read so far to the term. It is only exact when
+// the state is a match, otherwise it is the maximum distance plus one.
+func (a *Automaton) Distance(s State) int {
+	return s.row[len(s.row)-1]
+}
+
+// Match checks whether the string is within the distance of the term
+func (a *Automaton) Match(s string) bool {
+	state := a.Start()
+	for _, r := range s {
+		state = a.Step(state, r)
+		if !a.CanMatch(state) {
+			return false
+		}
+	}
+	return a.IsMatch(state)
+}
+
+func (a *Automaton) cap(d int) int {
+	if d > a.distance {
+		return a.distance + 1
+	}
+	return d
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,261 @@
+package fuzzy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// defaultMaxExpansions is the number of terms a fuzzy term expands to by default, the same as the
+// max_expansions default of elastic fuzzy queries.
+const defaultMaxExpansions = 50
+
+// TermDictionary provides the distinct terms stored in a field. It is usually backed by something
+// like a SELECT DISTINCT on the column that is run before rendering the query.
+type TermDictionary interface {
+	Terms(field string) ([]string, error)
+}
+
+// TermDictionaryFunc adapts a function to a TermDictionary
+type TermDictionaryFunc func(field string) ([]string, error)
+
+// Terms calls the function
+func (f TermDictionaryFunc) Terms(field string) ([]string, error) {
+	return f(field)
+}
+
+// StaticDictionary is a TermDictionary over a fixed set of terms per field
+type StaticDictionary map[string][]string
+
+// Terms returns the terms of the field
+func (d StaticDictionary) Terms(field string) ([]string, error) {
+	return d[field], nil
+}
+
+// Opt configures the expansion
+type Opt func(*expander)
+
+// WithMaxExpansions caps the number of terms a fuzzy term expands to. The closest terms are kept.
+// A max of 0 or less keeps every term within the distance.
+func WithMaxExpansions(n int) Opt {
+	return func(x *expander) {
+		x.maxExpansions = n
+	}
+}
+
+// WithDefaultField expands fuzzy terms without a field against the terms of the field
+func WithDefaultField(field string) Opt {
+	return func(x *expander) {
+		x.defaultField = field
+	}
+}
+
+type expander struct {
+	dict          TermDictionary
+	maxExpansions int
+	defaultField  string
+
+	// terms caches the dictionary per field for the duration of an expansion
+	terms map[string][]string
+}
+
+// Expand returns a copy of the expression where every fuzzy term is replaced by an IN list of the
+// terms of its field that are within its edit distance. The distance is the Damerau-Levenshtein
+// distance counted in runes and terms are compared case insensitively, the same as pkg/eval. The
+// list holds the terms as the dictionary returns them so it can be matched exactly. A fuzzy term
+// without any term close enough becomes an IN list of itself, which only matches the term.
+// The input expression is not modified.
+func Expand(e *expr.Expression, dict TermDictionary, opts ...Opt) (*expr.Expression, error) {
+	x := &expander{
+		dict:          dict,
+		maxExpansions: defaultMaxExpansions,
+		terms:         map[string][]string{},
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x.expand(e)
+}
+
+func (x *expander) expand(e *expr.Expression) (*expr.Expression, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	switch e.Op {
+	case expr.Fuzzy:
+		return x.fuzzy(e)
+	case expr.And, expr.Or, expr.Not, expr.Must, expr.MustNot, expr.Boost:
+		// copy the expression so the boost power is kept
+		out := *e
+		if left, isExpr := e.Left.(*expr.Expression); isExpr {
+			sub, err := x.expand(left)
+			if err != nil {
+				return nil, err
+			}
+			out.Left = sub
+		}
+		if right, isExpr := e.Right.(*expr.Expression); isExpr {
+			sub, err := x.expand(right)
+			if err != nil {
+				return nil, err
+			}
+			out.Right = sub
+		}
+		return &out, nil
+	case expr.Bool:
+		// copy the expression so the minimum_should_match is kept
+		out := *e
+		clauses := []*expr.BooleanClause{}
+		for _, c := range e.Clauses() {
+			sub, err := x.expand(c.Expr)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, &expr.BooleanClause{Occur: c.Occur, Expr: sub})
+		}
+		out.Left = clauses
+		return &out, nil
+	default:
+		return e, nil
+	}
+}
+
+func (x *expander) fuzzy(e *expr.Expression) (*expr.Expression, error) {
+	field, term, err := fuzzyTerm(e)
+	if err != nil {
+		return nil, err
+	}
+	if field == "" {
+		field = x.defaultField
+	}
+	if field == "" {
+		return nil, fmt.Errorf("unable to expand the fuzzy term %q without a field", term)
+	}
+
+	terms, cached := x.terms[field]
+	if !cached {
+		terms, err = x.dict.Terms(field)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the terms of %s: %w", field, err)
+		}
+		x.terms[field] = terms
+	}
+
+	matches := Intersect(NewAutomaton(strings.ToLower(term), e.FuzzyDistance()), terms)
+	if x.maxExpansions > 0 && len(matches) > x.maxExpansions {
+		matches = matches[:x.maxExpansions]
+	}
+
+	vals := []*expr.Expression{}
+	for _, m := range matches {
+		vals = append(vals, expr.Lit(m.Term))
+	}
+	if len(vals) == 0 {
+		vals = append(vals, expr.Lit(term))
+	}
+	return expr.IN(field, expr.LIST(vals)), nil
+}
+
+// fuzzyTerm returns the field and the term of a fuzzy expression. The field is empty for a bare
+// term.
+func fuzzyTerm(e *expr.Expression) (field, term string, err error) {
+	sub, isExpr := e.Left.(*expr.Expression)
+	if !isExpr || sub == nil {
+		return "", "", fmt.Errorf("FUZZY must wrap an expression, not %T", e.Left)
+	}
+
+	if sub.Op == expr.Equals {
+		left, isLeft := sub.Left.(*expr.Expression)
+		right, isRight := sub.Right.(*expr.Expression)
+		if !isLeft || left == nil || !isRight || right == nil {
+			return "", "", fmt.Errorf("FUZZY must wrap a term on a field")
+		}
+		switch v := left.Left.(type) {
+		case expr.Column:
+			field = string(v)
+		case string:
+			field = v
+		default:
+			return "", "", fmt.Errorf("FUZZY must have a column on the left, not %T", left.Left)
+		}
+		sub = right
+	}
+
+	s, isStr := sub.Left.(string)
+	if sub.Op != expr.Literal || !isStr {
+		return "", "", fmt.Errorf("FUZZY must wrap a string term, not %s", sub)
+	}
+	return field, s, nil
+}
+
+// Match is a term of the dictionary that matched an automaton
+type Match struct {
+	Term     string
+	Distance int
+}
+
+// Intersect returns the terms the automaton accepts ordered by their distance and then by term.
+// Terms are compared lower cased. The terms are walked in sorted order so terms sharing a prefix
+// share the states of the automaton and every term under a prefix that can't match is skipped.
+func Intersect(a *Automaton, terms []string) []Match {
+	lowered := make([]string, len(terms))
+	order := make([]int, len(terms))
+	for i, t := range terms {
+		lowered[i] = strings.ToLower(t)
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return lowered[order[i]] < lowered[order[j]]
+	})
+
+	out := []Match{}
+	// states[k] is the state after reading the first k runes of the previous term
+	states := []State{a.Start()}
+	var prev []rune
+	for _, i := range order {
+		runes := []rune(lowered[i])
+
+		shared := commonPrefix(prev, runes)
+		if shared < len(states)-1 {
+			states = states[:shared+1]
+		}
+		prev = runes
+
+		// the previous term died before the end of the shared prefix so this one can't match
+		if len(states) <= shared {
+			continue
+		}
+
+		alive := true
+		for _, r := range runes[len(states)-1:] {
+			next := a.Step(states[len(states)-1], r)
+			if !a.CanMatch(next) {
+				alive = false
+				break
+			}
+			states = append(states, next)
+		}
+		if alive && a.IsMatch(states[len(states)-1]) {
+			out = append(out, Match{Term: terms[i], Distance: a.Distance(states[len(states)-1])})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Distance != out[j].Distance {
+			return out[i].Distance < out[j].Distance
+		}
+		return out[i].Term < out[j].Term
+	})
+	return out
+}
+
+func commonPrefix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
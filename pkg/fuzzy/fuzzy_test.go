@@ -0,0 +1,114 @@
+package fuzzy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+func TestAutomaton(t *testing.T) {
+	words := []string{
+		"", "a", "ab", "ba", "abc", "acb", "bca", "kitten", "sitting", "smitten", "mitten",
+		"checkout", "chekcout", "chekout", "checkouts", "café", "cafe", "caféé", "ca", "xyz",
+	}
+
+	for _, term := range words {
+		for distance := 0; distance <= 3; distance++ {
+			a := NewAutomaton(term, distance)
+			for _, w := range words {
+				want := eval.EditDistance(term, w) <= distance
+				if got := a.Match(w); got != want {
+					t.Fatalf("Match(%q) of %q~%d = %v, want %v", w, term, distance, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	terms := []string{"checkout", "Checkout", "chekout", "checkouts", "cart", "catalog", "chec", "check", "c"}
+
+	got := Intersect(NewAutomaton("chekcout", 2), terms)
+	want := []Match{
+		{Term: "Checkout", Distance: 1},
+		{Term: "checkout", Distance: 1},
+		{Term: "chekout", Distance: 1},
+		{Term: "checkouts", Distance: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant %+v\ngot  %+v\n", want, got)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []Opt
+		want  *expr.Expression
+		err   string
+	}
+
+	dict := StaticDictionary{
+		"service": {"checkout", "chekout", "cart", "catalog", "checkouts"},
+		"message": {"refused", "refuse", "upstream"},
+	}
+
+	tcs := map[string]tc{
+		"term": {
+			input: "service:chekcout~1",
+			want:  expr.IN("service", expr.LIST(expr.Lit("checkout"), expr.Lit("chekout"))),
+		},
+		"max_expansions_keeps_the_closest": {
+			input: "service:chekcout~2",
+			opts:  []Opt{WithMaxExpansions(1)},
+			want:  expr.IN("service", expr.LIST(expr.Lit("checkout"))),
+		},
+		"no_match": {
+			input: "service:payments~1",
+			want:  expr.IN("service", expr.LIST(expr.Lit("payments"))),
+		},
+		"nested": {
+			input: "message:refsued~ AND NOT service:crat~^2",
+			want: expr.AND(
+				expr.IN("message", expr.LIST(expr.Lit("refused"))),
+				expr.NOT(expr.BOOST(expr.IN("service", expr.LIST(expr.Lit("cart"))), 2.0)),
+			),
+		},
+		"default_field": {
+			input: "upstraem~",
+			opts:  []Opt{WithDefaultField("message")},
+			want:  expr.IN("message", expr.LIST(expr.Lit("upstream"))),
+		},
+		"bare_term": {
+			input: "upstraem~",
+			err:   "without a field",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Expand(e, dict, tc.opts...)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error expanding: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %#v\ngot  %#v\n", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package highlight
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// Range is a matched byte range [Start, End) of a text value
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Field is a text value of the document with the ranges the query matched in it
+type Field struct {
+	Field string `json:"field"`
+	// Index is the position of the value in a multi valued field
+	Index   int     `json:"index"`
+	Text    string  `json:"text"`
+	Ranges  []Range `json:"ranges"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// Opt configures highlighting
+type Opt func(*highlighter)
+
+type highlighter struct {
+	marker  *Marker
+	context int
+}
+
+// Highlight returns the text values of the document the query matched in, ordered by field, with
+// the sorted and merged byte ranges of every match.
+//
+// The matching follows driverclick: terms and phrases are case insensitive substrings, wildcards
+// must match the whole value and highlight their literal parts, regexps are case insensitive and
+// highlight the whole value they match, IN lists highlight values they equal exactly and fuzzy terms highlight the words
+// within their edit distance. Terms without a field and terms on the _source field highlight the
+// _source value, or every text value if the document has no _source. Clauses under NOT and
+// MUST_NOT are never highlighted, neither are ranges and numeric or boolean terms.
+func Highlight(e *expr.Expression, doc map[string]any, opts ...Opt) ([]Field, error) {
+	h := &highlighter{}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	fields := map[string][]string{}
+	for field, vals := range tree.Flatten(doc) {
+		for _, v := range vals {
+			if s, isStr := v.(string); isStr {
+				fields[field] = append(fields[field], s)
+			}
+		}
+	}
+
+	ranges := map[string]map[int][]Range{}
+	if err := h.collect(e, fields, ranges); err != nil {
+		return nil, err
+	}
+
+	out := []Field{}
+	for field, byIndex := range ranges {
+		for i, rs := range byIndex {
+			f := Field{
+				Field:  field,
+				Index:  i,
+				Text:   fields[field][i],
+				Ranges: merge(rs),
+			}
+			if h.marker != nil {
+				f.Snippet = h.snippet(f.Text, f.Ranges)
+			}
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Field != out[j].Field {
+			return out[i].Field < out[j].Field
+		}
+		return out[i].Index < out[j].Index
+	})
+	return out, nil
+}
+
+func (h *highlighter) collect(e *expr.Expression, fields map[string][]string, out map[string]map[int][]Range) error {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case expr.Not, expr.MustNot:
+		// negated clauses didn't match anything worth highlighting
+		return nil
+	case expr.And, expr.Or:
+		if err := h.collect(sub(e.Left), fields, out); err != nil {
+			return err
+		}
+		return h.collect(sub(e.Right), fields, out)
+	case expr.Must, expr.Boost:
+		return h.collect(sub(e.Left), fields, out)
+	case expr.Bool:
+		for _, c := range e.Clauses() {
+			if c == nil || c.Occur == expr.OccurMustNot {
+				continue
+			}
+			if err := h.collect(c.Expr, fields, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	case expr.Literal, expr.Wild, expr.Regexp:
+		return find(sourceFields("", fields), e, fields, out)
+	case expr.Equals, expr.Like:
+		field, err := tree.Field(e)
+		if err != nil {
+			return err
+		}
+		return find(sourceFields(field, fields), sub(e.Right), fields, out)
+	case expr.In:
+		field, err := tree.Field(e)
+		if err != nil {
+			return err
+		}
+		list := sub(e.Right)
+		if list == nil {
+			return fmt.Errorf("IN must have a list on the right")
+		}
+		vals, _ := list.Left.([]*expr.Expression)
+		for _, v := range vals {
+			s, isStr := v.Left.(string)
+			if !isStr {
+				continue
+			}
+			for i, text := range fields[field] {
+				if text == s {
+					add(out, field, i, Range{0, len(text)})
+				}
+			}
+		}
+		return nil
+	case expr.Fuzzy:
+		return h.fuzzy(e, fields, out)
+	default:
+		// ranges and comparisons aren't text matches
+		return nil
+	}
+}
+
+// sourceFields applies the _source logic of driverclick.equals: a term on the _source field, or
+// without a field, searches the _source value. Documents without _source search every value.
+func sourceFields(field string, fields map[string][]string) []string {
+	if field != "" && field != eval.SourceField {
+		return []string{field}
+	}
+	if _, found := fields[eval.SourceField]; found {
+		return []string{eval.SourceField}
+	}
+
+	out := make([]string, 0, len(fields))
+	for f := range fields {
+		out = append(out, f)
+	}
+	return out
+}
+
+// find adds the ranges a term, phrase, wildcard or regexp matches in the values of the fields.
+func find(names []string, e *expr.Expression, fields map[string][]string, out map[string]map[int][]Range) error {
+	if e == nil {
+		return fmt.Errorf("expected an expression to highlight")
+	}
+
+	var matcher func(text string) []Range
+	switch e.Op {
+	case expr.Literal:
+		s, isStr := e.Left.(string)
+		if !isStr || s == "" {
+			// numbers and booleans are compared by value, not as text
+			return nil
+		}
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(s))
+		matcher = func(text string) []Range {
+			return ranges(re, text)
+		}
+	case expr.Wild:
+		m, err := wildcardMatcher(fmt.Sprintf("%v", e.Left))
+		if err != nil {
+			return err
+		}
+		matcher = m
+	case expr.Regexp:
+		pattern, err := regex.ToRE2(strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%v", e.Left), "/"), "/"))
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+		}
+		matcher = func(text string) []Range {
+			return ranges(re, text)
+		}
+	default:
+		return nil
+	}
+
+	for _, name := range names {
+		for i, text := range fields[name] {
+			for _, r := range matcher(text) {
+				add(out, name, i, r)
+			}
+		}
+	}
+	return nil
+}
+
+// wildcardMatcher matches the whole value against the wildcard and highlights its literal parts. A
+// leading or trailing * isn't highlighted so check* only marks the check prefix.
+func wildcardMatcher(pattern string) (func(text string) []Range, error) {
+	p := wildcard.Parse(pattern)
+
+	whole, err := regexp.Compile("(?i)" + p.RE2())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	spans := p.RE2Spans()
+	if spans == "" {
+		return func(text string) []Range {
+			if !whole.MatchString(text) {
+				return nil
+			}
+			return []Range{{0, len(text)}}
+		}, nil
+	}
+	re, err := regexp.Compile("(?i)" + spans)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return func(text string) []Range {
+		if !whole.MatchString(text) {
+			return nil
+		}
+		out := []Range{}
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			for g := 2; g+1 < len(loc); g += 2 {
+				if loc[g] >= 0 && loc[g+1] > loc[g] {
+					out = append(out, Range{loc[g], loc[g+1]})
+				}
+			}
+		}
+		return out
+	}, nil
+}
+
+// fuzzy highlights the words within the edit distance of the term.
+func (h *highlighter) fuzzy(e *expr.Expression, fields map[string][]string, out map[string]map[int][]Range) error {
+	term := sub(e.Left)
+	if term == nil {
+		return fmt.Errorf("FUZZY must wrap a term")
+	}
+
+	field := ""
+	if term.Op == expr.Equals {
+		var err error
+		field, err = tree.Field(term)
+		if err != nil {
+			return err
+		}
+		term = sub(term.Right)
+	}
+	if term == nil || term.Op != expr.Literal {
+		return fmt.Errorf("FUZZY must wrap a term")
+	}
+
+	want := strings.ToLower(fmt.Sprintf("%v", term.Left))
+	for _, name := range sourceFields(field, fields) {
+		for i, text := range fields[name] {
+			for _, w := range words(text) {
+				if eval.EditDistance(strings.ToLower(text[w.Start:w.End]), want) <= e.FuzzyDistance() {
+					add(out, name, i, w)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// words returns the ranges of the runs of letters and digits in the text
+func words(text string) []Range {
+	out := []Range{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			out = append(out, Range{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, Range{start, len(text)})
+	}
+	return out
+}
+
+func ranges(re *regexp.Regexp, text string) []Range {
+	out := []Range{}
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[1] > loc[0] {
+			out = append(out, Range{loc[0], loc[1]})
+		}
+	}
+	return out
+}
+
+func add(out map[string]map[int][]Range, field string, i int, r Range) {
+	byIndex, found := out[field]
+	if !found {
+		byIndex = map[int][]Range{}
+		out[field] = byIndex
+	}
+	byIndex[i] = append(byIndex[i], r)
+}
+
+// merge sorts the ranges and merges the ones that overlap or touch.
+func merge(in []Range) []Range {
+	sort.Slice(in, func(i, j int) bool {
+		return in[i].Start < in[j].Start
+	})
+
+	out := []Range{}
+	for _, r := range in {
+		if last := len(out) - 1; last >= 0 && r.Start <= out[last].End {
+			if r.End > out[last].End {
+				out[last].End = r.End
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func sub(in any) *expr.Expression {
+	e, _ := in.(*expr.Expression)
+	return e
+}
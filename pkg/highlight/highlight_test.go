@@ -0,0 +1,200 @@
+package highlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+)
+
+var testDoc = map[string]any{
+	"message": "Connection refused by upstream, upstream is down",
+	"level":   "error",
+	"service": "checkout-api",
+	"status":  502,
+	"tags":    []any{"prod", "eu-west"},
+	"http":    map[string]any{"path": "/api/v1/orders"},
+}
+
+func TestHighlight(t *testing.T) {
+	type tc struct {
+		input string
+		want  []Field
+		err   string
+	}
+
+	msg := testDoc["message"].(string)
+
+	tcs := map[string]tc{
+		"term": {
+			input: "message:upstream",
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{22, 30}, {32, 40}}}},
+		},
+		"case_insensitive": {
+			input: "message:connection",
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{0, 10}}}},
+		},
+		"phrase": {
+			input: `message:"refused by"`,
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{11, 21}}}},
+		},
+		"wildcard_prefix": {
+			input: "service:check*",
+			want:  []Field{{Field: "service", Text: "checkout-api", Ranges: []Range{{0, 5}}}},
+		},
+		"wildcard_parts": {
+			input: "service:*out*api",
+			want:  []Field{{Field: "service", Text: "checkout-api", Ranges: []Range{{5, 8}, {9, 12}}}},
+		},
+		"wildcard_must_match_whole_value": {
+			input: "service:out*",
+			want:  []Field{},
+		},
+		"regexp": {
+			input: "http.path:/.*v[0-9].*/",
+			want:  []Field{{Field: "http.path", Text: "/api/v1/orders", Ranges: []Range{{0, 14}}}},
+		},
+		"regexp_anchored": {
+			input: "http.path:/v[0-9]/",
+			want:  []Field{},
+		},
+		"invalid_regexp": {
+			input: "http.path:/a(/",
+			err:   "invalid pattern",
+		},
+		"multi_valued": {
+			input: "tags:west",
+			want:  []Field{{Field: "tags", Index: 1, Text: "eu-west", Ranges: []Range{{3, 7}}}},
+		},
+		"in": {
+			input: "level:(warn OR error)",
+			want:  []Field{{Field: "level", Text: "error", Ranges: []Range{{0, 5}}}},
+		},
+		"fuzzy": {
+			input: "message:refsued~",
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{11, 18}}}},
+		},
+		"bare_term_searches_every_field": {
+			input: "error",
+			want:  []Field{{Field: "level", Text: "error", Ranges: []Range{{0, 5}}}},
+		},
+		"skips_not": {
+			input: "message:down AND NOT level:error AND -service:checkout",
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{44, 48}}}},
+		},
+		"numbers_are_not_text": {
+			input: "status:502",
+			want:  []Field{},
+		},
+		"merges_overlaps": {
+			input: "message:refused OR message:used OR message:\"refused by\"",
+			want:  []Field{{Field: "message", Text: msg, Ranges: []Range{{11, 21}}}},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Highlight(e, testDoc)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error highlighting: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %+v\ngot  %+v\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestHighlightSource(t *testing.T) {
+	doc := map[string]any{
+		eval.SourceField: "GET /health refused",
+		"message":        "refused",
+	}
+
+	e, err := lucene.Parse("refused", lucene.WithDefaultField(eval.SourceField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Highlight(e, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Field{{Field: eval.SourceField, Text: "GET /health refused", Ranges: []Range{{12, 19}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant %+v\ngot  %+v\n", want, got)
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	type tc struct {
+		marker  Marker
+		context int
+		input   string
+		doc     map[string]any
+		want    string
+	}
+
+	tcs := map[string]tc{
+		"html_whole_text": {
+			marker: HTML,
+			input:  "message:b",
+			doc:    map[string]any{"message": "a <b> c"},
+			want:   "a &lt;<mark>b</mark>&gt; c",
+		},
+		"ansi": {
+			marker: ANSI,
+			input:  "message:refused",
+			doc:    map[string]any{"message": "connection refused"},
+			want:   "connection \x1b[1;33mrefused\x1b[0m",
+		},
+		"context": {
+			marker:  HTML,
+			context: 4,
+			input:   "message:refused OR message:down",
+			doc:     map[string]any{"message": "connection refused by upstream, upstream is down"},
+			want:    "…ion <mark>refused</mark> by …" + " is <mark>down</mark>",
+		},
+		"context_keeps_runes_whole": {
+			marker:  HTML,
+			context: 1,
+			input:   "message:b",
+			doc:     map[string]any{"message": "ééébééé"},
+			want:    "…é<mark>b</mark>é…",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Highlight(e, tc.doc, WithSnippets(tc.marker, tc.context))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("expected a single highlighted field but got %+v", got)
+			}
+			if got[0].Snippet != tc.want {
+				t.Fatalf("\nwant %q\ngot  %q\n", tc.want, got[0].Snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package highlight
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Marker wraps the highlighted parts of a snippet. Escape is applied to all text of the snippet
+// before it is marked.
+type Marker struct {
+	Open   string
+	Close  string
+	Escape func(string) string
+}
+
+// markers for the common outputs
+var (
+	// HTML marks matches with <mark> and escapes the text
+	HTML = Marker{Open: "<mark>", Close: "</mark>", Escape: html.EscapeString}
+	// ANSI marks matches in bold yellow for terminals
+	ANSI = Marker{Open: "\x1b[1;33m", Close: "\x1b[0m"}
+)
+
+// ellipsis marks text that was cut from a snippet
+const ellipsis = "…"
+
+// WithSnippets adds a snippet to every highlighted field that shows the matches marked with the
+// marker and up to context bytes of text around them. A context of zero or less keeps the whole
+// text.
+func WithSnippets(m Marker, context int) Opt {
+	return func(h *highlighter) {
+		h.marker = &m
+		h.context = context
+	}
+}
+
+// snippet renders the marked fragments of the text around the ranges
+func (h *highlighter) snippet(text string, ranges []Range) string {
+	frags := h.fragments(text, ranges)
+
+	var sb strings.Builder
+	for _, frag := range frags {
+		if frag.Start > 0 {
+			sb.WriteString(ellipsis)
+		}
+
+		pos := frag.Start
+		for _, r := range ranges {
+			if r.End <= frag.Start || r.Start >= frag.End {
+				continue
+			}
+			sb.WriteString(h.escape(text[pos:r.Start]))
+			sb.WriteString(h.marker.Open)
+			sb.WriteString(h.escape(text[r.Start:r.End]))
+			sb.WriteString(h.marker.Close)
+			pos = r.End
+		}
+		sb.WriteString(h.escape(text[pos:frag.End]))
+	}
+
+	if len(frags) > 0 && frags[len(frags)-1].End < len(text) {
+		sb.WriteString(ellipsis)
+	}
+	return sb.String()
+}
+
+// fragments widens the ranges by the context and merges the ones that overlap. The fragments
+// never split a rune.
+func (h *highlighter) fragments(text string, ranges []Range) []Range {
+	if h.context <= 0 {
+		return []Range{{0, len(text)}}
+	}
+
+	widened := make([]Range, 0, len(ranges))
+	for _, r := range ranges {
+		start, end := r.Start-h.context, r.End+h.context
+		if start < 0 {
+			start = 0
+		}
+		if end > len(text) {
+			end = len(text)
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+		widened = append(widened, Range{start, end})
+	}
+	return merge(widened)
+}
+
+func (h *highlighter) escape(s string) string {
+	if h.marker.Escape == nil {
+		return s
+	}
+	return h.marker.Escape(s)
+}
@@ -0,0 +1,215 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
+)
+
+// Index is an in-process inverted index that executes parsed lucene expressions. Text values are
+// analyzed into postings with positions so terms, phrases, wildcards, regexps and fuzzy terms can
+// be searched. Ranges, comparisons and non string values are evaluated against the stored source.
+// Nested maps are indexed under dotted field names (e.g. http.method) and every field is analyzed
+// with its analyzer, analysis.Standard by default.
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	mu sync.RWMutex
+
+	// docs are addressed by an internal number. Deleted documents leave a nil hole behind.
+	docs []*document
+	ids  map[string]int
+
+	// postings maps a field to its terms and each term to the documents and positions it occurs at
+	postings map[string]map[string]postingList
+
+	// fieldLengths is the sum of the number of tokens per field over all live documents
+	fieldLengths map[string]int
+	fieldDocs    map[string]int
+
+	analyzers analysis.PerField
+}
+
+type document struct {
+	ID     string         `json:"id"`
+	Source map[string]any `json:"source"`
+	// Lengths are the number of tokens per text field
+	Lengths map[string]int `json:"lengths"`
+}
+
+// postingList maps a document number to the positions a term occurs at
+type postingList map[int][]int
+
+// Hit is a single search result
+type Hit struct {
+	ID     string         `json:"id"`
+	Score  float64        `json:"score"`
+	Source map[string]any `json:"source"`
+}
+
+// Opt configures an Index
+type Opt func(*Index)
+
+// WithAnalyzers sets the analyzers used for indexing and searching each field. Fields without an
+// analyzer are indexed as a single keyword.
+func WithAnalyzers(analyzers analysis.PerField) Opt {
+	return func(idx *Index) {
+		idx.analyzers = analyzers
+	}
+}
+
+// New creates an empty index
+func New(opts ...Opt) *Index {
+	idx := &Index{
+		ids:          map[string]int{},
+		postings:     map[string]map[string]postingList{},
+		fieldLengths: map[string]int{},
+		fieldDocs:    map[string]int{},
+		analyzers:    analysis.PerField{Default: analysis.Standard},
+	}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	return idx
+}
+
+// Len returns the number of documents in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.ids)
+}
+
+// Add indexes the document under the id. A document that already has the id is replaced.
+func (idx *Index) Add(id string, doc map[string]any) error {
+	if id == "" {
+		return fmt.Errorf("document id must not be empty")
+	}
+
+	d := &document{
+		ID:      id,
+		Source:  doc,
+		Lengths: map[string]int{},
+	}
+	fields := tree.Flatten(doc)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if n, found := idx.ids[id]; found {
+		idx.remove(n)
+	}
+
+	n := len(idx.docs)
+	idx.docs = append(idx.docs, d)
+	idx.ids[id] = n
+	idx.indexDoc(n, d, fields)
+	return nil
+}
+
+// Delete removes the document with the id. It reports whether the document existed.
+func (idx *Index) Delete(id string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	n, found := idx.ids[id]
+	if !found {
+		return false
+	}
+	idx.remove(n)
+	return true
+}
+
+// Get returns the source of the document with the id.
+func (idx *Index) Get(id string) (map[string]any, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	n, found := idx.ids[id]
+	if !found {
+		return nil, false
+	}
+	return idx.docs[n].Source, true
+}
+
+// indexDoc adds the string values of the flattened fields to the postings.
+func (idx *Index) indexDoc(n int, d *document, fields map[string][]any) {
+	for field, vals := range fields {
+		a := idx.analyzer(field)
+		pos, length := 0, 0
+		for _, v := range vals {
+			s, isStr := v.(string)
+			if !isStr {
+				continue
+			}
+			tokens := a.Analyze(s)
+			for _, tok := range tokens {
+				terms, found := idx.postings[field]
+				if !found {
+					terms = map[string]postingList{}
+					idx.postings[field] = terms
+				}
+				pl, found := terms[tok.Term]
+				if !found {
+					pl = postingList{}
+					terms[tok.Term] = pl
+				}
+				pl[n] = append(pl[n], pos+tok.Position)
+			}
+			length += len(tokens)
+			if len(tokens) > 0 {
+				// leave a gap between the values of a multi valued field so phrases don't match across them
+				pos += tokens[len(tokens)-1].Position + 2
+			}
+		}
+
+		if length > 0 {
+			d.Lengths[field] = length
+			idx.fieldLengths[field] += length
+			idx.fieldDocs[field]++
+		}
+	}
+}
+
+func (idx *Index) remove(n int) {
+	d := idx.docs[n]
+	for field, terms := range idx.postings {
+		for term, pl := range terms {
+			delete(pl, n)
+			if len(pl) == 0 {
+				delete(terms, term)
+			}
+		}
+		if len(terms) == 0 {
+			delete(idx.postings, field)
+		}
+	}
+	for field, l := range d.Lengths {
+		idx.fieldLengths[field] -= l
+		idx.fieldDocs[field]--
+	}
+
+	delete(idx.ids, d.ID)
+	idx.docs[n] = nil
+}
+
+// live returns the numbers of all documents that are not deleted
+func (idx *Index) live() []int {
+	out := make([]int, 0, len(idx.ids))
+	for _, n := range idx.ids {
+		out = append(out, n)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// analyzer returns the analyzer of the field. Fields without one are a single keyword.
+func (idx *Index) analyzer(field string) *analysis.Analyzer {
+	if a := idx.analyzers.For(field); a != nil {
+		return a
+	}
+	return analysis.Keyword
+}
@@ -0,0 +1,278 @@
+package index
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
+)
+
+var testDocs = map[string]map[string]any{
+	"1": {
+		"message": "connection refused by upstream",
+		"level":   "error",
+		"status":  502,
+		"http":    map[string]any{"method": "GET", "path": "/api/v1/orders"},
+	},
+	"2": {
+		"message": "upstream connection established",
+		"level":   "info",
+		"status":  200,
+		"http":    map[string]any{"method": "POST", "path": "/api/v1/cart"},
+	},
+	"3": {
+		"message": "refused to serve the request, the upstream refused too",
+		"level":   "warn",
+		"status":  429,
+		"tags":    []any{"prod", "eu-west"},
+	},
+}
+
+func testIndex(t *testing.T) *Index {
+	t.Helper()
+	idx := New()
+	for id, doc := range testDocs {
+		if err := idx.Add(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx
+}
+
+func TestSearch(t *testing.T) {
+	type tc struct {
+		input string
+		want  []string
+		err   string
+	}
+
+	tcs := map[string]tc{
+		"term":                       {input: "message:refused", want: []string{"1", "3"}},
+		"term_case":                  {input: "level:ERROR", want: []string{"1"}},
+		"term_is_a_token":            {input: "message:refuse", want: []string{}},
+		"phrase":                     {input: `message:"connection refused"`, want: []string{"1"}},
+		"phrase_order":               {input: `message:"refused connection"`, want: []string{}},
+		"bare_term":                  {input: "upstream", want: []string{"1", "2", "3"}},
+		"source_field":               {input: "_source:established", want: []string{"2"}},
+		"nested_field":               {input: "http.method:post", want: []string{"2"}},
+		"multi_valued":               {input: "tags:west", want: []string{"3"}},
+		"wildcard":                   {input: "message:conn*", want: []string{"1", "2"}},
+		"wildcard_single":            {input: "level:wa?n", want: []string{"3"}},
+		"regexp":                     {input: "level:/(error|warn)/", want: []string{"1", "3"}},
+		"regexp_anchored":            {input: "level:/err/", want: []string{}},
+		"regexp_lucene_syntax":       {input: "level:/e@&~(info)/", err: "has no equivalent"},
+		"regexp_interval":            {input: "http.path:/v<1-2>/", want: []string{"1", "2"}},
+		"invalid_regexp":             {input: "level:/a(b/", err: "invalid pattern"},
+		"fuzzy":                      {input: "message:upstraem~", want: []string{"1", "2", "3"}},
+		"fuzzy_too_far":              {input: "level:eror~0", want: []string{}},
+		"number":                     {input: "status:200", want: []string{"2"}},
+		"range":                      {input: "status:[400 TO 499]", want: []string{"3"}},
+		"range_unbounded":            {input: "status:[500 TO *]", want: []string{"1"}},
+		"greater":                    {input: "status:>200", want: []string{"1", "3"}},
+		"in":                         {input: "level:(info OR warn)", want: []string{"2", "3"}},
+		"and":                        {input: "message:upstream AND level:error", want: []string{"1"}},
+		"or":                         {input: "level:error OR level:info", want: []string{"1", "2"}},
+		"not":                        {input: "NOT level:error", want: []string{"2", "3"}},
+		"must_not":                   {input: "upstream AND -level:info", want: []string{"1", "3"}},
+		"boolean_should_is_optional": {input: "+message:upstream level:info -status:429", want: []string{"1", "2"}},
+		"boolean_one_should":         {input: "level:error level:info -status:429", want: []string{"1", "2"}},
+		"grouped":                    {input: "(level:error OR level:warn) AND status:[400 TO 499]", want: []string{"3"}},
+		"missing_field":              {input: "nope:foo", want: []string{}},
+		"boost_keeps_match":          {input: "level:info^3", want: []string{"2"}},
+	}
+
+	idx := testIndex(t)
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hits, err := idx.Search(e)
+			if err != nil {
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error searching: %v", err)
+			}
+			if tc.err != "" {
+				t.Fatalf("expected error [%s] but got none", tc.err)
+			}
+
+			got := hitIDs(hits)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %v\ngot  %v\nparsed expression: %#v\n", tc.want, got, e)
+			}
+		})
+	}
+}
+
+func TestSearchScoring(t *testing.T) {
+	type tc struct {
+		input string
+		want  []string
+	}
+
+	tcs := map[string]tc{
+		// document 3 mentions refused twice
+		"term_frequency": {input: "message:refused", want: []string{"3", "1"}},
+		// established is rarer than upstream
+		"idf":   {input: "message:upstream OR message:established", want: []string{"2", "1", "3"}},
+		"boost": {input: "level:error OR level:info^10", want: []string{"2", "1"}},
+		// the optional clause only ranks the documents the required one matches
+		"should_ranks": {input: "+message:upstream level:warn", want: []string{"3", "2", "1"}},
+	}
+
+	idx := testIndex(t)
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			e, err := lucene.Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hits, err := idx.Search(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %v\ngot  %v\nhits: %+v\n", tc.want, got, hits)
+			}
+		})
+	}
+}
+
+func TestSearchWithAnalyzers(t *testing.T) {
+	idx := New(WithAnalyzers(analysis.PerField{
+		Default: analysis.Standard,
+		Fields:  map[string]*analysis.Analyzer{"message": analysis.English},
+	}))
+	for id, doc := range testDocs {
+		if err := idx.Add(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tcs := map[string][]string{
+		"message:connections": {"1", "2"},
+		"message:refusing":    {"1", "3"},
+		// the stopwords leave gaps so "refused by upstream" matches
+		`message:"refused the upstream"`: {"1"},
+		`message:"serve request"`:        {},
+		"level:ERROR":                    {"1"},
+	}
+
+	for input, want := range tcs {
+		t.Run(input, func(t *testing.T) {
+			e, err := lucene.Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hits, err := idx.Search(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := hitIDs(hits)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("\nwant %v\ngot  %v\n", want, got)
+			}
+		})
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	idx := testIndex(t)
+	e, err := lucene.Parse("upstream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := idx.Search(e, WithLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits but got %d", len(hits))
+	}
+}
+
+func TestAddAndDelete(t *testing.T) {
+	idx := testIndex(t)
+	e, err := lucene.Parse("level:error")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.Add("1", map[string]any{"level": "info"}); err != nil {
+		t.Fatal(err)
+	}
+	hits, err := idx.Search(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("expected a replaced document to be reindexed but got %v", hitIDs(hits))
+	}
+
+	if !idx.Delete("2") || idx.Delete("2") {
+		t.Fatalf("expected only the first delete to find the document")
+	}
+	if idx.Len() != 2 {
+		t.Fatalf("expected 2 documents but got %d", idx.Len())
+	}
+	if err := idx.Add("", nil); err == nil {
+		t.Fatalf("expected an error adding a document without an id")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	idx := testIndex(t)
+	idx.Delete("2")
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := idx.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{"upstream", `message:"connection refused"`, "status:[400 TO 599]", "level:warn^2"} {
+		e, err := lucene.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := idx.Search(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := loaded.Search(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(hitIDs(got), hitIDs(want)) {
+			t.Fatalf("%s: want %v from the snapshot but got %v", input, hitIDs(want), hitIDs(got))
+		}
+	}
+
+	if _, err := Load(bytes.NewBufferString(`{"version": 99}`)); err == nil {
+		t.Fatalf("expected an error loading an unknown snapshot version")
+	}
+}
+
+func hitIDs(hits []Hit) []string {
+	out := []string{}
+	for _, h := range hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
@@ -0,0 +1,486 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AlxBystrov/go-lucene/internal/tree"
+	"github.com/AlxBystrov/go-lucene/pkg/analysis"
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+	"github.com/AlxBystrov/go-lucene/pkg/regex"
+	"github.com/AlxBystrov/go-lucene/pkg/wildcard"
+)
+
+// BM25 parameters, the same defaults lucene uses
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// SearchOpt configures a search
+type SearchOpt func(*search)
+
+// WithLimit returns at most n hits. A limit of zero or less returns every hit.
+func WithLimit(n int) SearchOpt {
+	return func(s *search) {
+		s.limit = n
+	}
+}
+
+// WithDefaultFields sets the fields bare terms are searched in. By default every indexed text
+// field is searched.
+func WithDefaultFields(fields ...string) SearchOpt {
+	return func(s *search) {
+		s.defaultFields = fields
+	}
+}
+
+type search struct {
+	idx           *Index
+	limit         int
+	defaultFields []string
+}
+
+// scores maps a document number to its score
+type scores map[int]float64
+
+// Search executes the expression and returns the matching documents ordered by their BM25 score.
+// Documents with the same score are ordered by id.
+//
+// Terms and phrases are analyzed with the analyzer of their field, the same way the documents
+// were, and scored with BM25. Wildcards and regexps are expanded against the terms of the field
+// and, like in lucene, given a constant score. Fuzzy terms are expanded to every term within their edit distance. Ranges, comparisons and
+// equality on numbers and booleans filter on the document values with a constant score. BOOST
+// multiplies the score of its clause by its power, NOT clauses never add to the score and the
+// optional clauses of a boolean query only add to the score of the documents it matches. A bare
+// term or a term on the _source field searches every default field.
+func (idx *Index) Search(e *expr.Expression, opts ...SearchOpt) ([]Hit, error) {
+	s := &search{idx: idx}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var matched scores
+	if e == nil {
+		matched = s.all(0)
+	} else {
+		var err error
+		matched, err = s.exec(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hits := make([]Hit, 0, len(matched))
+	for n, score := range matched {
+		d := idx.docs[n]
+		hits = append(hits, Hit{ID: d.ID, Score: score, Source: d.Source})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	if s.limit > 0 && len(hits) > s.limit {
+		hits = hits[:s.limit]
+	}
+	return hits, nil
+}
+
+func (s *search) exec(e *expr.Expression) (scores, error) {
+	switch e.Op {
+	case expr.And, expr.Or:
+		left, right, err := tree.Operands(e)
+		if err != nil {
+			return nil, err
+		}
+		l, err := s.exec(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := s.exec(right)
+		if err != nil {
+			return nil, err
+		}
+		if e.Op == expr.And {
+			return intersect(l, r), nil
+		}
+		return union(l, r), nil
+	case expr.Not, expr.MustNot:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		excluded, err := s.exec(sub)
+		if err != nil {
+			return nil, err
+		}
+		out := s.all(0)
+		for n := range excluded {
+			delete(out, n)
+		}
+		return out, nil
+	case expr.Must:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		return s.exec(sub)
+	case expr.Boost:
+		sub, err := tree.Operand(e)
+		if err != nil {
+			return nil, err
+		}
+		out, err := s.exec(sub)
+		if err != nil {
+			return nil, err
+		}
+		for n := range out {
+			out[n] *= e.BoostPower()
+		}
+		return out, nil
+	case expr.Bool:
+		return s.boolean(e)
+	case expr.Literal, expr.Wild, expr.Regexp:
+		return s.text(s.fields(""), e)
+	case expr.Equals, expr.Like:
+		field, err := tree.Field(e)
+		if err != nil {
+			return nil, err
+		}
+		right, err := tree.Expr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		if right.Op == expr.Literal {
+			if _, isStr := right.Left.(string); !isStr {
+				return s.filter(e)
+			}
+		}
+		return s.text(s.fields(field), right)
+	case expr.In:
+		field, err := tree.Field(e)
+		if err != nil {
+			return nil, err
+		}
+		list, err := tree.Expr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		vals, isList := list.Left.([]*expr.Expression)
+		if list.Op != expr.List || !isList {
+			return nil, fmt.Errorf("IN must have a list on the right, not %s", list.Op)
+		}
+		out := scores{}
+		for _, v := range vals {
+			matched, err := s.exec(expr.Eq(expr.Column(field), v))
+			if err != nil {
+				return nil, err
+			}
+			out = union(out, matched)
+		}
+		return out, nil
+	case expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		return s.filter(e)
+	case expr.Fuzzy:
+		return s.fuzzy(e)
+	default:
+		return nil, fmt.Errorf("unable to search operator [%s]", e.Op)
+	}
+}
+
+// boolean scores a lucene boolean query. The documents have to match every MUST clause, none of the
+// MUST_NOT clauses and the required number of SHOULD clauses. Their score is the sum of the scores
+// of the MUST and SHOULD clauses they match so optional clauses still rank the results.
+func (s *search) boolean(e *expr.Expression) (scores, error) {
+	var out scores
+	excluded := scores{}
+	matchedShoulds := map[int]int{}
+	shouldScores := scores{}
+	for _, c := range e.Clauses() {
+		if c == nil || c.Expr == nil {
+			return nil, fmt.Errorf("%s must not have empty clauses", e.Op)
+		}
+		matched, err := s.exec(c.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c.Occur {
+		case expr.OccurMust:
+			if out == nil {
+				out = matched
+				continue
+			}
+			out = intersect(out, matched)
+		case expr.OccurMustNot:
+			excluded = union(excluded, matched)
+		default:
+			for n, score := range matched {
+				matchedShoulds[n]++
+				shouldScores[n] += score
+			}
+		}
+	}
+
+	if out == nil {
+		out = s.all(0)
+	}
+	required := e.RequiredShould()
+	for n := range out {
+		if _, found := excluded[n]; found || matchedShoulds[n] < required {
+			delete(out, n)
+			continue
+		}
+		out[n] += shouldScores[n]
+	}
+	return out, nil
+}
+
+// fields returns the fields a term on the field is searched in
+func (s *search) fields(field string) []string {
+	if field != "" && (field != eval.SourceField || s.idx.postings[field] != nil) {
+		return []string{field}
+	}
+	if len(s.defaultFields) > 0 {
+		return s.defaultFields
+	}
+
+	out := make([]string, 0, len(s.idx.postings))
+	for f := range s.idx.postings {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// text searches a term, phrase, wildcard or regexp in the fields. A document matching in several
+// fields gets the best of its scores.
+func (s *search) text(fields []string, e *expr.Expression) (scores, error) {
+	out := scores{}
+	for _, field := range fields {
+		var matched scores
+		var err error
+		switch e.Op {
+		case expr.Literal:
+			matched = s.phrase(field, s.idx.analyzer(field).Analyze(fmt.Sprintf("%v", e.Left)))
+		case expr.Wild, expr.Regexp:
+			matched, err = s.pattern(field, e)
+		default:
+			return nil, fmt.Errorf("unable to search %s as text", e.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for n, score := range matched {
+			out[n] = math.Max(out[n], score)
+		}
+	}
+	return out, nil
+}
+
+// phrase matches documents that have the tokens at the same relative positions. A single token
+// is a plain term query.
+func (s *search) phrase(field string, tokens []analysis.Token) scores {
+	out := scores{}
+	if len(tokens) == 0 {
+		return out
+	}
+
+	lists := make([]postingList, len(tokens))
+	idf := 0.0
+	for i, tok := range tokens {
+		pl := s.idx.postings[field][tok.Term]
+		if len(pl) == 0 {
+			return out
+		}
+		lists[i] = pl
+		idf += s.idf(len(pl))
+	}
+
+	for n, positions := range lists[0] {
+		freq := 0
+		for _, pos := range positions {
+			if phraseAt(lists[1:], tokens[1:], n, pos-tokens[0].Position) {
+				freq++
+			}
+		}
+		if freq > 0 {
+			out[n] = idf * s.tf(field, n, freq)
+		}
+	}
+	return out
+}
+
+// phraseAt checks whether the remaining tokens of a phrase that starts at the position are at
+// their relative positions in the document.
+func phraseAt(lists []postingList, tokens []analysis.Token, n, start int) bool {
+	for i, pl := range lists {
+		if !containsInt(pl[n], start+tokens[i].Position) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(vals []int, want int) bool {
+	for _, v := range vals {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// pattern expands a wildcard or regexp against the terms of the field. Matching documents get a
+// constant score.
+func (s *search) pattern(field string, e *expr.Expression) (scores, error) {
+	re, err := termRegexp(e)
+	if err != nil {
+		return nil, err
+	}
+
+	out := scores{}
+	for term, pl := range s.idx.postings[field] {
+		if !re.MatchString(term) {
+			continue
+		}
+		for n := range pl {
+			out[n] = 1
+		}
+	}
+	return out, nil
+}
+
+// termRegexp compiles a wildcard or regexp into a regexp matching whole terms. Just like in lucene
+// both are anchored and regexps follow the lucene syntax.
+func termRegexp(e *expr.Expression) (*regexp.Regexp, error) {
+	var pattern string
+	switch e.Op {
+	case expr.Wild:
+		pattern = wildcard.Parse(strings.ToLower(fmt.Sprintf("%v", e.Left))).RE2()
+	case expr.Regexp:
+		translated, err := regex.ToRE2(strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%v", e.Left), "/"), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+		}
+		pattern = translated
+	default:
+		return nil, fmt.Errorf("expected a wildcard or regexp, not %s", e.Op)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", e.Left, err)
+	}
+	return re, nil
+}
+
+// fuzzy expands the term to every term of the field within the edit distance. A document gets the
+// best BM25 score of the terms it contains.
+func (s *search) fuzzy(e *expr.Expression) (scores, error) {
+	sub, err := tree.Expr(e.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	field, term := "", sub
+	if sub.Op == expr.Equals {
+		field, err = tree.Field(sub)
+		if err != nil {
+			return nil, err
+		}
+		term, err = tree.Expr(sub.Right)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if term.Op != expr.Literal {
+		return nil, fmt.Errorf("FUZZY must wrap a term, not %s", term.Op)
+	}
+
+	want := strings.ToLower(fmt.Sprintf("%v", term.Left))
+	out := scores{}
+	for _, f := range s.fields(field) {
+		for t := range s.idx.postings[f] {
+			if eval.EditDistance(t, want) > e.FuzzyDistance() {
+				continue
+			}
+			for n, score := range s.phrase(f, []analysis.Token{{Term: t}}) {
+				out[n] = math.Max(out[n], score)
+			}
+		}
+	}
+	return out, nil
+}
+
+// filter matches the expression against the source of every document with a constant score.
+func (s *search) filter(e *expr.Expression) (scores, error) {
+	out := scores{}
+	for _, n := range s.idx.live() {
+		matched, err := eval.Match(e, s.idx.docs[n].Source)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			out[n] = 1
+		}
+	}
+	return out, nil
+}
+
+// all returns every live document with the score
+func (s *search) all(score float64) scores {
+	out := make(scores, len(s.idx.ids))
+	for _, n := range s.idx.ids {
+		out[n] = score
+	}
+	return out
+}
+
+// idf is the BM25 inverse document frequency of a term that occurs in df documents
+func (s *search) idf(df int) float64 {
+	total := float64(len(s.idx.ids))
+	return math.Log(1 + (total-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// tf is the BM25 term frequency normalised by the length of the field in the document
+func (s *search) tf(field string, n, freq int) float64 {
+	avg := 1.0
+	if docs := s.idx.fieldDocs[field]; docs > 0 {
+		avg = float64(s.idx.fieldLengths[field]) / float64(docs)
+	}
+	length := float64(s.idx.docs[n].Lengths[field])
+	f := float64(freq)
+	return f * (k1 + 1) / (f + k1*(1-b+b*length/avg))
+}
+
+func intersect(l, r scores) scores {
+	out := scores{}
+	for n, score := range l {
+		if other, found := r[n]; found {
+			out[n] = score + other
+		}
+	}
+	return out
+}
+
+func union(l, r scores) scores {
+	out := make(scores, len(l)+len(r))
+	for n, score := range l {
+		out[n] = score
+	}
+	for n, score := range r {
+		out[n] += score
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// snapshotVersion is bumped whenever the snapshot format changes
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version  int                               `json:"version"`
+	Docs     []*document                       `json:"docs"`
+	Postings map[string]map[string]postingList `json:"postings"`
+}
+
+// Save writes a snapshot of the index to w. Deleted documents are compacted away so the snapshot
+// only contains live documents.
+func (idx *Index) Save(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// renumber the live documents so the snapshot has no holes
+	renumbered := map[int]int{}
+	snap := snapshot{
+		Version:  snapshotVersion,
+		Docs:     make([]*document, 0, len(idx.ids)),
+		Postings: make(map[string]map[string]postingList, len(idx.postings)),
+	}
+	for _, n := range idx.live() {
+		renumbered[n] = len(snap.Docs)
+		snap.Docs = append(snap.Docs, idx.docs[n])
+	}
+
+	for field, terms := range idx.postings {
+		out := make(map[string]postingList, len(terms))
+		for term, pl := range terms {
+			compacted := make(postingList, len(pl))
+			for n, positions := range pl {
+				compacted[renumbered[n]] = positions
+			}
+			out[term] = compacted
+		}
+		snap.Postings[field] = out
+	}
+
+	if err := json.NewEncoder(w).Encode(snap); err != nil {
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads an index from a snapshot written by Save. Numbers in the documents are decoded as
+// json.Number so integers keep their precision. Analyzers aren't part of the snapshot so the same
+// options the index was created with must be passed again.
+func Load(r io.Reader, opts ...Opt) (*Index, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var snap snapshot
+	if err := dec.Decode(&snap); err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	idx := New(opts...)
+	idx.docs = snap.Docs
+	for n, d := range snap.Docs {
+		if d == nil {
+			return nil, fmt.Errorf("snapshot has an empty document at %d", n)
+		}
+		idx.ids[d.ID] = n
+		for field, l := range d.Lengths {
+			idx.fieldLengths[field] += l
+			idx.fieldDocs[field]++
+		}
+	}
+
+	for field, terms := range snap.Postings {
+		for term, pl := range terms {
+			for n := range pl {
+				if n < 0 || n >= len(idx.docs) {
+					return nil, fmt.Errorf("posting for %s:%s references unknown document %d", field, term, n)
+				}
+			}
+		}
+	}
+	if snap.Postings != nil {
+		idx.postings = snap.Postings
+	}
+	return idx, nil
+}
+
+// SaveFile writes a snapshot of the index to the file at path. The snapshot is written to a
+// temporary file first and renamed so an existing snapshot is never left half written.
+func (idx *Index) SaveFile(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("unable to create snapshot: %w", err)
+	}
+
+	if err := idx.Save(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadFile reads an index from a snapshot file written by SaveFile.
+func LoadFile(path string, opts ...Opt) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot: %w", err)
+	}
+	defer f.Close()
+	return Load(f, opts...)
+}
@@ -0,0 +1,231 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FindingKind is the kind of problem the analyzer found in an expression
+type FindingKind int
+
+// kinds of findings
+const (
+	// Contradiction is a subexpression that can never match
+	Contradiction FindingKind = iota + 1
+	// Tautology is a subexpression that always matches
+	Tautology
+)
+
+// String renders the finding kind as a string
+func (k FindingKind) String() string {
+	switch k {
+	case Contradiction:
+		return "CONTRADICTION"
+	case Tautology:
+		return "TAUTOLOGY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Finding points at a subexpression that is unsatisfiable or always true.
+type Finding struct {
+	Kind   FindingKind `json:"kind"`
+	Node   *Expression `json:"node"`
+	Reason string      `json:"reason"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s [%s]", f.Kind, f.Reason, f.Node)
+}
+
+// Analyze looks for subexpressions that can never match, such as age:[10 TO 20] AND age:>30 or
+// a:x AND NOT a:x, and for subexpressions that always match, such as a:x OR NOT a:x. Findings
+// point at the innermost offending nodes of the expression. If the problem makes the whole
+// expression unsatisfiable (or always true) the root is reported too.
+//
+// By default field:value is read as the field containing the value, the way the drivers match it,
+// so a:x AND a:y can both hold. Numbers are compared with the single number of the field so
+// status:200 AND status:500 or age:5 AND age:[10 TO 20] are always reported. The fields declared
+// as Keyword with WithFieldKind hold a single value whatever it is.
+func Analyze(e *Expression, opts ...ConstraintOpt) []Finding {
+	if e == nil {
+		return nil
+	}
+
+	a := &analyzer{semantics: newSemantics(opts)}
+	status := a.walk(e)
+
+	if status != 0 && !a.reported(e) {
+		a.findings = append(a.findings, Finding{
+			Kind:   status,
+			Node:   e,
+			Reason: rootReason(status),
+		})
+	}
+	return a.findings
+}
+
+func rootReason(status FindingKind) string {
+	if status == Contradiction {
+		return "the expression can never match"
+	}
+	return "the expression always matches"
+}
+
+type analyzer struct {
+	semantics *semantics
+	findings  []Finding
+}
+
+func (a *analyzer) reported(e *Expression) bool {
+	for _, f := range a.findings {
+		if f.Node == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) report(kind FindingKind, e *Expression, reason string) FindingKind {
+	a.findings = append(a.findings, Finding{Kind: kind, Node: e, Reason: reason})
+	return kind
+}
+
+// walk returns the status of the subexpression: a Contradiction, a Tautology or 0 if it depends on
+// the document.
+func (a *analyzer) walk(e *Expression) FindingKind {
+	switch e.Op {
+	case And:
+		return a.walkAnd(e)
+	case Or:
+		return a.walkOr(e)
+	case Not, MustNot:
+		sub, isExpr := e.Left.(*Expression)
+		if !isExpr {
+			return 0
+		}
+		return negateStatus(a.walk(sub))
+	case Must, Boost:
+		sub, isExpr := e.Left.(*Expression)
+		if !isExpr {
+			return 0
+		}
+		return a.walk(sub)
+	case Bool:
+		return a.walk(ToFilter(e))
+	case Range:
+		_, iv, ok := toInterval(e)
+		if ok && iv.isEmpty() {
+			return a.report(Contradiction, e, fmt.Sprintf("the range %s is empty", e))
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func negateStatus(status FindingKind) FindingKind {
+	switch status {
+	case Contradiction:
+		return Tautology
+	case Tautology:
+		return Contradiction
+	default:
+		return 0
+	}
+}
+
+func (a *analyzer) walkAnd(e *Expression) FindingKind {
+	terms := flatten(And, e)
+
+	statuses := []FindingKind{}
+	for _, t := range terms {
+		statuses = append(statuses, a.walk(t))
+	}
+	// a contradicting operand was already reported, the AND just inherits it
+	if anyStatus(statuses, Contradiction) {
+		return Contradiction
+	}
+	if allStatus(statuses, Tautology) {
+		return Tautology
+	}
+
+	if pair := complementPair(terms); pair != nil {
+		return a.report(Contradiction, e, fmt.Sprintf("%s and %s can't both match", pair[0], pair[1]))
+	}
+
+	cons, _ := constraints(terms, a.semantics)
+	for _, col := range sortedColumns(cons) {
+		if reason := cons[col].unsatisfiable(col); reason != "" {
+			return a.report(Contradiction, e, reason)
+		}
+	}
+	return 0
+}
+
+func (a *analyzer) walkOr(e *Expression) FindingKind {
+	terms := flatten(Or, e)
+
+	statuses := []FindingKind{}
+	for _, t := range terms {
+		statuses = append(statuses, a.walk(t))
+	}
+	if anyStatus(statuses, Tautology) {
+		return Tautology
+	}
+	if allStatus(statuses, Contradiction) {
+		return Contradiction
+	}
+
+	if pair := complementPair(terms); pair != nil {
+		return a.report(Tautology, e, fmt.Sprintf("either %s or %s always matches", pair[0], pair[1]))
+	}
+	return 0
+}
+
+// complementPair finds an operand and its negation among the operands.
+func complementPair(terms []*Expression) []*Expression {
+	for _, t := range terms {
+		if t.Op != Not && t.Op != MustNot {
+			continue
+		}
+		negated, isExpr := t.Left.(*Expression)
+		if !isExpr {
+			continue
+		}
+		for _, other := range terms {
+			if Equal(other, negated) {
+				return []*Expression{other, t}
+			}
+		}
+	}
+	return nil
+}
+
+func anyStatus(statuses []FindingKind, want FindingKind) bool {
+	for _, s := range statuses {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+func allStatus(statuses []FindingKind, want FindingKind) bool {
+	for _, s := range statuses {
+		if s != want {
+			return false
+		}
+	}
+	return len(statuses) > 0
+}
+
+func sortedColumns(cons map[Column]*fieldConstraint) []Column {
+	cols := []Column{}
+	for col := range cons {
+		cols = append(cols, col)
+	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i] < cols[j] })
+	return cols
+}
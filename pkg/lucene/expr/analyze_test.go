@@ -0,0 +1,126 @@
+package expr
+
+import (
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	type want struct {
+		kind FindingKind
+		node *Expression
+	}
+
+	type tc struct {
+		input *Expression
+		opts  []ConstraintOpt
+		want  []want
+	}
+
+	conflictingEq := AND(Eq("status", 200), Eq("status", 500))
+	disjointRanges := AND(Rang("age", 10, 20, true), GREATER("age", 30))
+	selfNegation := AND(Eq("a", "x"), NOT(Eq("a", "x")))
+	excludedIn := AND(IN("a", LIST(Lit("x"), Lit("y"))), AND(NOT(Eq("a", "x")), MUSTNOT(Eq("a", "y"))))
+	outOfRange := AND(Eq("age", 5), Rang("age", 10, 20, true))
+	emptyRange := Rang("age", 20, 10, true)
+	orSelfNegation := OR(Eq("a", "x"), NOT(Eq("a", "x")))
+	nestedOr := OR(conflictingEq, Eq("b", 1))
+	nestedAnd := AND(Eq("b", 1), disjointRanges)
+	negatedTautology := NOT(orSelfNegation)
+
+	tcs := map[string]tc{
+		"satisfiable": {
+			input: AND(Eq("status", 200), Rang("age", 10, 20, true)),
+		},
+		"same_value_twice": {
+			input: AND(Eq("status", 200), Eq("status", 200.0)),
+		},
+		"overlapping_ranges": {
+			input: AND(Rang("age", 10, 20, true), GREATER("age", 15)),
+		},
+		"different_fields": {
+			input: AND(Eq("a", 1), Eq("b", 2)),
+		},
+		"contained_values": {
+			input: AND(Eq("msg", "foo"), Eq("msg", "bar")),
+		},
+		"contained_value_out_of_range": {
+			input: outOfRange,
+			want:  []want{{Contradiction, outOfRange}},
+		},
+		"contained_string_out_of_range": {
+			input: AND(Eq("name", "bob"), Rang("name", "x", "z", true)),
+		},
+		"disjoint_string_ranges": {
+			input: AND(Rang("a", "a", "c", true), Rang("a", "x", "z", true)),
+		},
+		"conflicting_equalities": {
+			input: conflictingEq,
+			opts:  []ConstraintOpt{WithFieldKind("status", Keyword)},
+			want:  []want{{Contradiction, conflictingEq}},
+		},
+		"conflicting_numbers": {
+			input: conflictingEq,
+			want:  []want{{Contradiction, conflictingEq}},
+		},
+		"disjoint_ranges": {
+			input: disjointRanges,
+			want:  []want{{Contradiction, disjointRanges}},
+		},
+		"self_negation": {
+			input: selfNegation,
+			want:  []want{{Contradiction, selfNegation}},
+		},
+		"every_value_excluded": {
+			input: excludedIn,
+			want:  []want{{Contradiction, excludedIn}},
+		},
+		"value_out_of_range": {
+			input: outOfRange,
+			opts:  []ConstraintOpt{WithFieldKind("age", Keyword)},
+			want:  []want{{Contradiction, outOfRange}},
+		},
+		"empty_range": {
+			input: emptyRange,
+			want:  []want{{Contradiction, emptyRange}},
+		},
+		"tautology": {
+			input: orSelfNegation,
+			want:  []want{{Tautology, orSelfNegation}},
+		},
+		"nested_contradiction_in_or": {
+			input: nestedOr,
+			opts:  []ConstraintOpt{WithFieldKind("status", Keyword)},
+			want:  []want{{Contradiction, conflictingEq}},
+		},
+		"nested_contradiction_in_and": {
+			input: nestedAnd,
+			// the nested AND is flattened into its parent so the whole chain is reported
+			want: []want{{Contradiction, nestedAnd}},
+		},
+		"negated_tautology": {
+			input: negatedTautology,
+			want:  []want{{Tautology, orSelfNegation}, {Contradiction, negatedTautology}},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got := Analyze(tc.input, tc.opts...)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d findings but got %d: %v", len(tc.want), len(got), got)
+			}
+
+			for i, w := range tc.want {
+				if got[i].Kind != w.kind {
+					t.Fatalf("finding %d: expected kind %s but got %s", i, w.kind, got[i].Kind)
+				}
+				if got[i].Node != w.node {
+					t.Fatalf("finding %d: expected node %s but got %s", i, w.node, got[i].Node)
+				}
+				if got[i].Reason == "" {
+					t.Fatalf("finding %d: expected a reason", i)
+				}
+			}
+		})
+	}
+}
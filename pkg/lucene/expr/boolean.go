@@ -0,0 +1,230 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxBoolCombinations caps how many combinations of SHOULD clauses a minimum_should_match can
+// expand to when a BOOL expression is rewritten into a plain filter.
+const maxBoolCombinations = 1024
+
+// Occur is how a clause takes part in a BOOL expression. It mirrors lucene's BooleanClause.Occur.
+type Occur int
+
+const (
+	// OccurShould clauses are optional. A document has to match at least minimum_should_match of
+	// them, or one of them if the query has no MUST clauses.
+	OccurShould Occur = iota
+	// OccurMust clauses have to match
+	OccurMust
+	// OccurMustNot clauses must not match
+	OccurMustNot
+)
+
+var occurToString = map[Occur]string{
+	OccurShould:  "SHOULD",
+	OccurMust:    "MUST",
+	OccurMustNot: "MUST_NOT",
+}
+
+var occurFromString = map[string]Occur{
+	"SHOULD":   OccurShould,
+	"MUST":     OccurMust,
+	"MUST_NOT": OccurMustNot,
+}
+
+// String renders the occur type as a string
+func (o Occur) String() string {
+	return occurToString[o]
+}
+
+// MarshalJSON serializes the occur type as its name
+func (o Occur) MarshalJSON() ([]byte, error) {
+	s, found := occurToString[o]
+	if !found {
+		return nil, fmt.Errorf("unsupported occur type %d", o)
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON deserializes the occur type from its name
+func (o *Occur) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	occur, found := occurFromString[s]
+	if !found {
+		return fmt.Errorf("unsupported occur type %q", s)
+	}
+	*o = occur
+	return nil
+}
+
+// BooleanClause is a single clause of a BOOL expression
+type BooleanClause struct {
+	Occur Occur       `json:"occur"`
+	Expr  *Expression `json:"clause"`
+}
+
+// Clause creates a boolean clause
+func Clause(occur Occur, e any) *BooleanClause {
+	return &BooleanClause{Occur: occur, Expr: literalToExpr(e)}
+}
+
+// BOOL creates a lucene boolean query out of the clauses. The optional minimum_should_match is the
+// number of SHOULD clauses a document has to match.
+func BOOL(clauses []*BooleanClause, minimumShouldMatch ...int) *Expression {
+	if len(minimumShouldMatch) > 0 {
+		return Expr(clauses, Bool, minimumShouldMatch[0])
+	}
+	return Expr(clauses, Bool)
+}
+
+// Clauses returns the clauses of a BOOL expression. It is nil for every other operator.
+func (e Expression) Clauses() []*BooleanClause {
+	clauses, _ := e.Left.([]*BooleanClause)
+	return clauses
+}
+
+// MinimumShouldMatch returns the minimum_should_match of a BOOL expression. It is 0 for every
+// other operator.
+func (e Expression) MinimumShouldMatch() int {
+	return e.minShouldMatch
+}
+
+// RequiredShould returns how many SHOULD clauses a document has to match to match the BOOL
+// expression. Like in lucene a query without MUST clauses needs at least one of its SHOULD
+// clauses, otherwise they only contribute to the score unless minimum_should_match is set.
+func (e Expression) RequiredShould() int {
+	if e.minShouldMatch > 0 {
+		return e.minShouldMatch
+	}
+
+	musts, shoulds := 0, 0
+	for _, c := range e.Clauses() {
+		switch c.Occur {
+		case OccurMust:
+			musts++
+		case OccurShould:
+			shoulds++
+		}
+	}
+	if musts == 0 && shoulds > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ToFilter returns a copy of the expression where every BOOL expression is rewritten into the
+// AND, OR and NOT expressions that match the same documents. SHOULD clauses that are only used for
+// scoring are dropped and a minimum_should_match is expanded into an OR of the combinations of
+// SHOULD clauses that satisfy it. A BOOL without MUST or SHOULD clauses keeps the semantics of
+// MUST_NOT everywhere else and matches the documents that match none of its clauses.
+// The input expression is not modified.
+func ToFilter(e *Expression) *Expression {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case Bool:
+		terms, shoulds := []*Expression{}, []*Expression{}
+		// the SHOULD clauses take the place of the first one so the clauses keep their order
+		shouldAt := -1
+		for _, c := range e.Clauses() {
+			sub := ToFilter(c.Expr)
+			switch c.Occur {
+			case OccurMust:
+				terms = append(terms, sub)
+			case OccurMustNot:
+				terms = append(terms, NOT(sub))
+			default:
+				if shouldAt < 0 {
+					shouldAt = len(terms)
+				}
+				shoulds = append(shoulds, sub)
+			}
+		}
+
+		if required := e.RequiredShould(); required > 0 && len(shoulds) > 0 {
+			terms = append(terms[:shouldAt], append([]*Expression{atLeast(required, shoulds)}, terms[shouldAt:]...)...)
+		}
+		return chain(And, terms)
+	case Literal, Wild, Regexp, List, Equals, Like, In, Range, Greater, Less, GreaterEq, LessEq:
+		return e
+	default:
+		// keep the operator specific state (boost power, fuzzy distance) by copying the node
+		out := *e
+		if left, isExpr := e.Left.(*Expression); isExpr {
+			out.Left = ToFilter(left)
+		}
+		if right, isExpr := e.Right.(*Expression); isExpr {
+			out.Right = ToFilter(right)
+		}
+		return &out
+	}
+}
+
+// atLeast builds a filter that matches when at least n of the terms match.
+func atLeast(n int, terms []*Expression) *Expression {
+	if n > len(terms) {
+		n = len(terms)
+	}
+
+	switch n {
+	case 1:
+		return chain(Or, terms)
+	case len(terms):
+		return chain(And, terms)
+	}
+
+	alternatives := []*Expression{}
+	for _, combination := range combinations(len(terms), n) {
+		picked := make([]*Expression, 0, n)
+		for _, i := range combination {
+			picked = append(picked, terms[i])
+		}
+		alternatives = append(alternatives, chain(And, picked))
+	}
+	return chain(Or, alternatives)
+}
+
+// combinations returns every sorted combination of k indexes out of n
+func combinations(n, k int) [][]int {
+	out := [][]int{}
+	combination := make([]int, 0, k)
+
+	var pick func(start int)
+	pick = func(start int) {
+		if len(combination) == k {
+			out = append(out, append([]int{}, combination...))
+			return
+		}
+		for i := start; i <= n-(k-len(combination)); i++ {
+			combination = append(combination, i)
+			pick(i + 1)
+			combination = combination[:len(combination)-1]
+		}
+	}
+	pick(0)
+	return out
+}
+
+// binomial returns the number of combinations of k items out of n. It stops counting once the
+// count goes over max.
+func binomial(n, k, max int) int {
+	if k > n-k {
+		k = n - k
+	}
+
+	count := 1
+	for i := 1; i <= k; i++ {
+		count = count * (n - k + i) / i
+		if count > max {
+			return count
+		}
+	}
+	return count
+}
@@ -0,0 +1,135 @@
+package expr
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToFilter(t *testing.T) {
+	type tc struct {
+		input      *Expression
+		wantFilter *Expression
+		wantString string
+	}
+
+	a, b, c, d := Eq("a", 1), Eq("b", 2), Eq("c", 3), Eq("d", 4)
+
+	tcs := map[string]tc{
+		"should_is_optional_next_to_must": {
+			input:      BOOL([]*BooleanClause{Clause(OccurMust, a), Clause(OccurShould, b), Clause(OccurMustNot, c)}),
+			wantFilter: AND(a, NOT(c)),
+			wantString: "+a:1 b:2 -c:3",
+		},
+		"one_should_is_required_without_must": {
+			input:      BOOL([]*BooleanClause{Clause(OccurShould, a), Clause(OccurShould, b), Clause(OccurMustNot, c)}),
+			wantFilter: AND(OR(a, b), NOT(c)),
+			wantString: "a:1 b:2 -c:3",
+		},
+		"only_must_not": {
+			input:      BOOL([]*BooleanClause{Clause(OccurMustNot, a), Clause(OccurMustNot, b)}),
+			wantFilter: AND(NOT(a), NOT(b)),
+			wantString: "-a:1 -b:2",
+		},
+		"minimum_should_match_with_must": {
+			input:      BOOL([]*BooleanClause{Clause(OccurMust, a), Clause(OccurShould, b), Clause(OccurShould, c)}, 1),
+			wantFilter: AND(a, OR(b, c)),
+			wantString: "+a:1 +(b:2 OR c:3)",
+		},
+		"minimum_should_match_combinations": {
+			input: BOOL([]*BooleanClause{
+				Clause(OccurShould, a), Clause(OccurShould, b), Clause(OccurShould, c),
+			}, 2),
+			wantFilter: OR(OR(AND(a, b), AND(a, c)), AND(b, c)),
+			wantString: "+(a:1 AND b:2 OR a:1 AND c:3 OR b:2 AND c:3)",
+		},
+		"minimum_should_match_all": {
+			input:      BOOL([]*BooleanClause{Clause(OccurShould, a), Clause(OccurShould, b), Clause(OccurMustNot, d)}, 2),
+			wantFilter: AND(AND(a, b), NOT(d)),
+			wantString: "-d:4 +(a:1 AND b:2)",
+		},
+		"nested": {
+			input: OR(
+				BOOL([]*BooleanClause{Clause(OccurMust, OR(a, b)), Clause(OccurShould, c)}),
+				BOOST(BOOL([]*BooleanClause{Clause(OccurShould, d)}), 2),
+			),
+			wantFilter: OR(OR(a, b), BOOST(d, 2)),
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(tc.input); err != nil {
+				t.Fatalf("expected a valid expression but got [%s]", err)
+			}
+
+			got := ToFilter(tc.input)
+			if !sameExpr(got, tc.wantFilter) {
+				t.Fatalf(errTemplate, "filter doesn't match", tc.wantFilter, got)
+			}
+
+			if tc.wantString != "" && tc.input.String() != tc.wantString {
+				t.Fatalf("\nwant %s\ngot  %s\n", tc.wantString, tc.input)
+			}
+		})
+	}
+}
+
+func TestBoolValidation(t *testing.T) {
+	shoulds := []*BooleanClause{}
+	for i := 0; i < 20; i++ {
+		shoulds = append(shoulds, Clause(OccurShould, Eq("a", i)))
+	}
+
+	tcs := map[string]struct {
+		input *Expression
+		err   string
+	}{
+		"no_clauses":             {input: BOOL(nil), err: "at least one clause"},
+		"negative_minimum":       {input: BOOL(shoulds[:2], -1), err: "must not be negative"},
+		"minimum_above_shoulds":  {input: BOOL(shoulds[:2], 3), err: "is more than the 2 SHOULD clauses"},
+		"too_many_combinations":  {input: BOOL(shoulds, 10), err: "more than 1024 combinations"},
+		"invalid_clause":         {input: BOOL([]*BooleanClause{Clause(OccurMust, AND(Eq("a", 1), nil))}), err: "right value must not be nil"},
+		"every_should_is_needed": {input: BOOL(shoulds, 20)},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.input)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("expected no error but got [%s]", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error [%s] but got [%v]", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestBoolJSON(t *testing.T) {
+	e := BOOL([]*BooleanClause{
+		Clause(OccurMust, Eq("a", "b")),
+		Clause(OccurShould, IN("c", LIST(Lit("d"), Lit("e")))),
+		Clause(OccurMustNot, Rang("f", 1, 5, true)),
+	}, 1)
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"occur":"MUST_NOT"`) || !strings.Contains(string(raw), `"minimum_should_match":1`) {
+		t.Fatalf("unexpected json %s", raw)
+	}
+
+	var got Expression
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, &got) {
+		t.Fatalf(errTemplate, "expressions don't match after a round trip", e, &got)
+	}
+}
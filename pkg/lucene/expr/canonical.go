@@ -0,0 +1,187 @@
+package expr
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonical returns a copy of the expression with a stable ordering. AND and OR chains are
+// flattened and their operands sorted, and the values of IN lists are sorted. Two expressions
+// that only differ in the order of commutative operands have the same canonical form.
+// The input expression is not modified.
+func Canonical(e *Expression) *Expression {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case And, Or:
+		terms := []*Expression{}
+		for _, t := range flatten(e.Op, e) {
+			terms = append(terms, Canonical(t))
+		}
+		sortExprs(terms)
+		return chain(e.Op, terms)
+	case List:
+		vals, isList := e.Left.([]*Expression)
+		if !isList {
+			return e
+		}
+		sorted := append([]*Expression{}, vals...)
+		sortExprs(sorted)
+		return LIST(sorted)
+	case Bool:
+		// the clauses of each occur type are just as commutative as the operands of AND and OR
+		clauses := []*BooleanClause{}
+		for _, c := range e.Clauses() {
+			clauses = append(clauses, &BooleanClause{Occur: c.Occur, Expr: Canonical(c.Expr)})
+		}
+		sort.SliceStable(clauses, func(i, j int) bool {
+			if clauses[i].Occur != clauses[j].Occur {
+				return clauses[i].Occur < clauses[j].Occur
+			}
+			return key(clauses[i].Expr) < key(clauses[j].Expr)
+		})
+		out := *e
+		out.Left = clauses
+		return &out
+	case Literal, Wild, Regexp:
+		return e
+	default:
+		// keep the operator specific state (boost power, fuzzy distance) by copying the node
+		out := *e
+		out.Left = canonicalAny(e.Left)
+		out.Right = canonicalAny(e.Right)
+		return &out
+	}
+}
+
+func canonicalAny(in any) any {
+	e, isExpr := in.(*Expression)
+	if !isExpr {
+		return in
+	}
+	return Canonical(e)
+}
+
+func sortExprs(exprs []*Expression) {
+	keys := make(map[*Expression]string, len(exprs))
+	for _, e := range exprs {
+		keys[e] = key(e)
+	}
+	sort.SliceStable(exprs, func(i, j int) bool {
+		return keys[exprs[i]] < keys[exprs[j]]
+	})
+}
+
+// Equal checks whether two expressions are structurally equal up to the ordering of commutative
+// operands, e.g. b:2 AND a:1 is equal to a:1 AND b:2. Operator parameters such as the boost power
+// and fuzzy distance are part of the comparison. Filters that are logically the same but written
+// differently, e.g. a:[1 TO 5] and a:[1 TO 3] OR a:[2 TO 5], are not equal.
+func Equal(a, b *Expression) bool {
+	return key(Canonical(a)) == key(Canonical(b))
+}
+
+// Hash returns a stable 64 bit hash of the canonical form of the expression. Expressions that
+// are Equal have the same hash.
+func Hash(e *Expression) uint64 {
+	h := fnv.New64a()
+	// writing to a hash never returns an error
+	_, _ = h.Write([]byte(key(Canonical(e))))
+	return h.Sum64()
+}
+
+// Fingerprint returns the hash of the expression as a fixed width hex string. It is suitable
+// as a cache key.
+func (e Expression) Fingerprint() string {
+	return fmt.Sprintf("%016x", Hash(&e))
+}
+
+// sameExpr checks whether two expressions are structurally identical, including the ordering
+// of their operands.
+func sameExpr(a, b *Expression) bool {
+	return key(a) == key(b)
+}
+
+// key serializes the expression into an unambiguous string. Unlike String and GoString it
+// tags every literal with its type and always includes the operator parameters.
+func key(e *Expression) string {
+	var sb strings.Builder
+	writeKey(&sb, e)
+	return sb.String()
+}
+
+func writeKey(sb *strings.Builder, in any) {
+	switch v := in.(type) {
+	case nil:
+		sb.WriteString("nil")
+	case *Expression:
+		if v == nil {
+			sb.WriteString("nil")
+			return
+		}
+		sb.WriteString(toString[v.Op])
+		switch v.Op {
+		case Boost:
+			sb.WriteString("[" + strconv.FormatFloat(v.boostPower, 'g', -1, 64) + "]")
+		case Fuzzy:
+			sb.WriteString("[" + strconv.Itoa(v.fuzzyDistance) + "]")
+		case Bool:
+			sb.WriteString("[" + strconv.Itoa(v.minShouldMatch) + "]")
+		}
+		sb.WriteString("(")
+		writeKey(sb, v.Left)
+		if v.Right != nil {
+			sb.WriteString(",")
+			writeKey(sb, v.Right)
+		}
+		sb.WriteString(")")
+	case []*Expression:
+		sb.WriteString("[")
+		for i, e := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			writeKey(sb, e)
+		}
+		sb.WriteString("]")
+	case []*BooleanClause:
+		sb.WriteString("[")
+		for i, c := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(c.Occur.String() + ":")
+			writeKey(sb, c.Expr)
+		}
+		sb.WriteString("]")
+	case *RangeBoundary:
+		if v.Inclusive {
+			sb.WriteString("incl[")
+		} else {
+			sb.WriteString("excl[")
+		}
+		writeKey(sb, v.Min)
+		sb.WriteString(",")
+		writeKey(sb, v.Max)
+		sb.WriteString("]")
+	case Column:
+		sb.WriteString("col:" + strconv.Quote(string(v)))
+	case string:
+		sb.WriteString("str:" + strconv.Quote(v))
+	case bool:
+		sb.WriteString("bool:" + strconv.FormatBool(v))
+	default:
+		switch {
+		case isInt(v):
+			sb.WriteString(fmt.Sprintf("int:%d", v))
+		case isFloat(v):
+			sb.WriteString("float:" + strconv.FormatFloat(toFloat(v), 'g', -1, 64))
+		default:
+			sb.WriteString(fmt.Sprintf("%T:%v", v, v))
+		}
+	}
+}
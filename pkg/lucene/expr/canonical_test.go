@@ -0,0 +1,108 @@
+package expr
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	type tc struct {
+		a    *Expression
+		b    *Expression
+		want bool
+	}
+
+	tcs := map[string]tc{
+		"identical": {
+			a:    Eq("a", 1),
+			b:    Eq("a", 1),
+			want: true,
+		},
+		"commutative_and": {
+			a:    AND(Eq("b", 2), Eq("a", 1)),
+			b:    AND(Eq("a", 1), Eq("b", 2)),
+			want: true,
+		},
+		"commutative_nested_or": {
+			a:    OR(Eq("c", 3), OR(Eq("b", 2), Eq("a", 1))),
+			b:    OR(OR(Eq("a", 1), Eq("b", 2)), Eq("c", 3)),
+			want: true,
+		},
+		"in_list_order": {
+			a:    IN("a", LIST(Lit("y"), Lit("x"))),
+			b:    IN("a", LIST(Lit("x"), Lit("y"))),
+			want: true,
+		},
+		"different_values": {
+			a:    Eq("a", 1),
+			b:    Eq("a", 2),
+			want: false,
+		},
+		"int_and_string_differ": {
+			a:    Eq("a", 1),
+			b:    Eq("a", "1"),
+			want: false,
+		},
+		"and_or_differ": {
+			a:    AND(Eq("a", 1), Eq("b", 2)),
+			b:    OR(Eq("a", 1), Eq("b", 2)),
+			want: false,
+		},
+		"range_inclusivity_differs": {
+			a:    Rang("a", 1, 5, true),
+			b:    Rang("a", 1, 5, false),
+			want: false,
+		},
+		"boost_power_differs": {
+			a:    BOOST(Eq("a", 1), 2),
+			b:    BOOST(Eq("a", 1), 3),
+			want: false,
+		},
+		"fuzzy_distance_differs": {
+			a:    FUZZY(Eq("a", "foo"), 1),
+			b:    FUZZY(Eq("a", "foo"), 2),
+			want: false,
+		},
+		"not_is_not_commutative": {
+			a:    AND(NOT(Eq("a", 1)), Eq("b", 2)),
+			b:    AND(Eq("a", 1), NOT(Eq("b", 2))),
+			want: false,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			if got := Equal(tc.a, tc.b); got != tc.want {
+				t.Fatalf("Equal(%#v, %#v) = %v, want %v", tc.a, tc.b, got, tc.want)
+			}
+
+			sameHash := Hash(tc.a) == Hash(tc.b)
+			if sameHash != tc.want {
+				t.Fatalf("Hash equality for %#v and %#v = %v, want %v", tc.a, tc.b, sameHash, tc.want)
+			}
+
+			sameFingerprint := tc.a.Fingerprint() == tc.b.Fingerprint()
+			if sameFingerprint != tc.want {
+				t.Fatalf("Fingerprint equality for %#v and %#v = %v, want %v", tc.a, tc.b, sameFingerprint, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	in := AND(Eq("b", 2), AND(Eq("a", 1), IN("c", LIST(Lit("z"), Lit("x")))))
+	before := in.GoString()
+
+	want := AND(AND(Eq("a", 1), Eq("b", 2)), IN("c", LIST(Lit("x"), Lit("z"))))
+	got := Canonical(in)
+	if !sameExpr(got, want) {
+		t.Fatalf(errTemplate, "canonical expression doesn't match", want, got)
+	}
+
+	if in.GoString() != before {
+		t.Fatalf("input expression was modified: %#v", in)
+	}
+
+	if len(in.Fingerprint()) != 16 {
+		t.Fatalf("expected a 16 character fingerprint, got %q", in.Fingerprint())
+	}
+}
@@ -0,0 +1,288 @@
+package expr
+
+import "fmt"
+
+// FieldKind is how field:value matches the values of a field
+type FieldKind int
+
+const (
+	// Contains is the default kind, field:value matches the documents whose field contains the value
+	// the way the drivers, the evaluator and the index do it with a substring or a token. A document
+	// can match several values of the field so a:x AND a:y can both hold.
+	Contains FieldKind = iota
+	// Keyword fields hold a single value that field:value matches exactly, so a:x AND a:y can't
+	// both hold.
+	Keyword
+)
+
+// ConstraintOpt configures how Analyze and Implies reason about the fields
+type ConstraintOpt func(*semantics)
+
+// WithFieldKind sets how field:value matches the values of a field
+func WithFieldKind(field string, kind FieldKind) ConstraintOpt {
+	return func(s *semantics) {
+		if s.kinds == nil {
+			s.kinds = map[Column]FieldKind{}
+		}
+		s.kinds[Column(field)] = kind
+	}
+}
+
+// WithNormalFormOpts sets the options of the conversions to the normal forms Implies compares
+func WithNormalFormOpts(opts ...NormalFormOpt) ConstraintOpt {
+	return func(s *semantics) {
+		s.normal = append(s.normal, opts...)
+	}
+}
+
+type semantics struct {
+	kinds  map[Column]FieldKind
+	normal []NormalFormOpt
+}
+
+func newSemantics(opts []ConstraintOpt) *semantics {
+	s := &semantics{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *semantics) keyword(col Column) bool {
+	return s.kinds[col] == Keyword
+}
+
+// fieldConstraint is the set of values a single field can take in a conjunction of clauses.
+type fieldConstraint struct {
+	// keyword is set for the Keyword fields, a:x AND a:y can't both hold on them
+	keyword bool
+
+	// allowed is the set of values a keyword field must be one of. It is only meaningful if
+	// hasAllowed is set.
+	allowed    []*Expression
+	hasAllowed bool
+
+	// anyOf are the sets of values a field that isn't a keyword must contain one of each
+	anyOf [][]*Expression
+
+	// excluded are values the field must not equal
+	excluded []*Expression
+
+	// iv is the interval the field must fall in. It starts out unbounded on both sides.
+	iv interval
+
+	// comparable is false if the clauses mix values that can't be ordered (e.g. numbers and strings)
+	// in which case we can't reason about the field.
+	comparable bool
+}
+
+func newFieldConstraint(keyword bool) *fieldConstraint {
+	return &fieldConstraint{keyword: keyword, comparable: true}
+}
+
+// constraints collects the per field constraints from the clauses of a conjunction. Clauses that
+// can't be expressed as a field constraint are returned untouched in rest. Only the numeric ranges
+// and equalities bound the fields that aren't keywords.
+func constraints(terms []*Expression, s *semantics) (cons map[Column]*fieldConstraint, rest []*Expression) {
+	cons = map[Column]*fieldConstraint{}
+	get := func(col Column) *fieldConstraint {
+		c, found := cons[col]
+		if !found {
+			c = newFieldConstraint(s.keyword(col))
+			cons[col] = c
+		}
+		return c
+	}
+
+	for _, t := range terms {
+		negated := false
+		leaf := t
+		if (t.Op == Not || t.Op == MustNot) && IsExpr(t.Left) {
+			negated = true
+			leaf = t.Left.(*Expression)
+		}
+		if leaf.Op == Must && IsExpr(leaf.Left) {
+			leaf = leaf.Left.(*Expression)
+		}
+
+		if col, vals, ok := inValues(leaf); ok {
+			if negated {
+				get(col).exclude(vals)
+			} else {
+				get(col).allow(vals)
+			}
+			continue
+		}
+
+		if col, iv, ok := toInterval(leaf); ok && !negated && (s.keyword(col) || iv.numeric()) {
+			get(col).restrict(iv)
+			continue
+		}
+
+		rest = append(rest, t)
+	}
+	return cons, rest
+}
+
+// allow narrows the allowed values of a keyword field to the intersection with vals, and so do
+// numbers on any field since it is compared with a single number. A field that isn't a keyword
+// must contain one of the other vals besides the values it already contains.
+func (c *fieldConstraint) allow(vals []*Expression) {
+	if !c.single(vals) {
+		c.anyOf = append(c.anyOf, vals)
+		return
+	}
+
+	if !c.hasAllowed {
+		c.allowed = append([]*Expression{}, vals...)
+		c.hasAllowed = true
+		return
+	}
+
+	out := []*Expression{}
+	for _, v := range c.allowed {
+		if containsValue(vals, v) {
+			out = append(out, v)
+		}
+	}
+	c.allowed = out
+}
+
+// single checks whether the field holds a single value when it is compared with vals
+func (c *fieldConstraint) single(vals []*Expression) bool {
+	if c.keyword {
+		return true
+	}
+	for _, v := range vals {
+		if !isNum(v.Left) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *fieldConstraint) exclude(vals []*Expression) {
+	c.excluded = append(c.excluded, vals...)
+}
+
+// restrict narrows the interval of the field.
+func (c *fieldConstraint) restrict(iv interval) {
+	next, ok := intersect(c.iv, iv)
+	if !ok {
+		c.comparable = false
+		return
+	}
+	c.iv = next
+}
+
+// candidates returns the allowed values that also fall in the interval and are not excluded.
+func (c *fieldConstraint) candidates() []*Expression {
+	out := []*Expression{}
+	for _, v := range c.allowed {
+		if containsValue(c.excluded, v) {
+			continue
+		}
+		if in, ok := c.iv.contains(v); ok && !in {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// unsatisfiable reports why no value of the field can satisfy the constraint. It returns an
+// empty reason if the constraint can be satisfied or we can't tell.
+func (c *fieldConstraint) unsatisfiable(col Column) (reason string) {
+	if !c.comparable {
+		return ""
+	}
+
+	if c.iv.isEmpty() {
+		return fmt.Sprintf("the ranges on %s don't overlap", col)
+	}
+
+	for _, vals := range c.anyOf {
+		if c.allExcluded(vals) {
+			return fmt.Sprintf("every value allowed for %s is excluded", col)
+		}
+	}
+
+	if !c.hasAllowed {
+		return ""
+	}
+
+	if len(c.allowed) == 0 {
+		return fmt.Sprintf("%s can't equal several different values at once", col)
+	}
+
+	if len(c.candidates()) == 0 {
+		return fmt.Sprintf("every value allowed for %s is excluded or out of range", col)
+	}
+
+	return ""
+}
+
+// allExcluded checks whether every one of vals is excluded
+func (c *fieldConstraint) allExcluded(vals []*Expression) bool {
+	for _, v := range vals {
+		if !containsValue(c.excluded, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains checks whether the value falls inside the interval. It is not ok if the value
+// can't be compared with the bounds.
+func (iv interval) contains(v *Expression) (in bool, ok bool) {
+	if iv.min.value != nil {
+		cmp, ok := compareValues(v.Left, iv.min.value.Left)
+		if !ok {
+			return false, false
+		}
+		if cmp < 0 || (cmp == 0 && !iv.min.inclusive) {
+			return false, true
+		}
+	}
+
+	if iv.max.value != nil {
+		cmp, ok := compareValues(v.Left, iv.max.value.Left)
+		if !ok {
+			return false, false
+		}
+		if cmp > 0 || (cmp == 0 && !iv.max.inclusive) {
+			return false, true
+		}
+	}
+
+	return true, true
+}
+
+// numeric checks whether the bounds of the interval are numbers
+func (iv interval) numeric() bool {
+	for _, bd := range []bound{iv.min, iv.max} {
+		if bd.value != nil && !isNum(bd.value.Left) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue checks whether two literal values are equal. Numbers compare numerically so 1 and 1.0
+// are the same value.
+func sameValue(a, b *Expression) bool {
+	if isNum(a.Left) && isNum(b.Left) {
+		cmp, _ := compareValues(a.Left, b.Left)
+		return cmp == 0
+	}
+	return sameExpr(a, b)
+}
+
+func containsValue(list []*Expression, v *Expression) bool {
+	for _, l := range list {
+		if sameValue(l, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -37,8 +37,9 @@ type Expression struct {
 	Right any      `json:"right,omitempty"`
 
 	// these are operator specific states we have to track
-	boostPower    float64
-	fuzzyDistance int
+	boostPower     float64
+	fuzzyDistance  int
+	minShouldMatch int
 }
 
 // RangeBoundary represents the boundary conditions for a range operator
@@ -72,6 +73,16 @@ func (e Expression) GoString() string {
 	return renderer(&e, true)
 }
 
+// BoostPower returns the power of a BOOST expression. It is 1 for every other operator.
+func (e Expression) BoostPower() float64 {
+	return e.boostPower
+}
+
+// FuzzyDistance returns the edit distance of a FUZZY expression. It is 1 for every other operator.
+func (e Expression) FuzzyDistance() int {
+	return e.fuzzyDistance
+}
+
 // Lit represents a literal expression
 func Lit(in any) *Expression {
 	return Expr(in, Literal)
@@ -190,6 +201,13 @@ func Validate(in any) (err error) {
 		return err
 	}
 
+	for _, c := range e.Clauses() {
+		err = Validate(c.Expr)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = Validate(e.Left)
 	if err != nil {
 		return err
@@ -246,6 +264,14 @@ func Expr(left any, op Operator, right ...any) *Expression {
 		return e
 	}
 
+	// support setting the minimum_should_match of a boolean query
+	if op == Bool {
+		if len(right) == 1 && isInt(right[0]) {
+			e.minShouldMatch = right[0].(int)
+		}
+		return e
+	}
+
 	// support passing a range with inclusivity
 	if op == Range && len(right) == 3 && isBool(right[2]) {
 		e.Right = &RangeBoundary{
@@ -296,9 +322,10 @@ type jsonExpression struct {
 	Operator string          `json:"operator"`
 	Right    json.RawMessage `json:"right,omitempty"`
 
-	RangeBoundary *RangeBoundary `json:"boundaries,omitempty"`
-	FuzzyDistance *int           `json:"distance,omitempty"`
-	BoostPower    *float64       `json:"power,omitempty"`
+	RangeBoundary      *RangeBoundary `json:"boundaries,omitempty"`
+	FuzzyDistance      *int           `json:"distance,omitempty"`
+	BoostPower         *float64       `json:"power,omitempty"`
+	MinimumShouldMatch *int           `json:"minimum_should_match,omitempty"`
 }
 
 // MarshalJSON is a custom JSON serialization for the Expression
@@ -336,6 +363,10 @@ func (e Expression) MarshalJSON() (out []byte, err error) {
 		c.FuzzyDistance = &e.fuzzyDistance
 	}
 
+	if e.minShouldMatch != 0 {
+		c.MinimumShouldMatch = &e.minShouldMatch
+	}
+
 	return json.Marshal(c)
 }
 
@@ -359,6 +390,21 @@ func (e *Expression) UnmarshalJSON(data []byte) (err error) {
 		return err
 	}
 
+	// the left hand side of a boolean query is its list of clauses
+	if fromString[c.Operator] == Bool {
+		var clauses []*BooleanClause
+		err = json.Unmarshal(c.Left, &clauses)
+		if err != nil {
+			return err
+		}
+		e.Left = clauses
+		e.Op = Bool
+		if c.MinimumShouldMatch != nil {
+			e.minShouldMatch = *c.MinimumShouldMatch
+		}
+		return nil
+	}
+
 	// check if it is an array so we can parse it into literals
 	if isArray(json.RawMessage(c.Left)) {
 		var l []json.RawMessage
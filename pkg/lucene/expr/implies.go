@@ -0,0 +1,261 @@
+package expr
+
+// Tristate is a boolean answer that can also be unknown
+type Tristate int
+
+// possible answers
+const (
+	Unknown Tristate = iota
+	True
+	False
+)
+
+// String renders the tristate as a string
+func (t Tristate) String() string {
+	switch t {
+	case True:
+		return "TRUE"
+	case False:
+		return "FALSE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Implies checks whether every document matched by a is also matched by b. The answer is exact for
+// conjunctions of equality, IN and range constraints. Everywhere else it is conservative: it only
+// answers True or False when it can prove it and Unknown otherwise. Like Analyze it reads
+// field:value as the field containing the value, so a:x implies neither a:y nor NOT a:y. The
+// exact answers for several values of a field need the field to be declared as a Keyword with
+// WithFieldKind.
+func Implies(a, b *Expression, opts ...ConstraintOpt) Tristate {
+	if a == nil || b == nil {
+		return Unknown
+	}
+
+	if Equal(a, b) {
+		return True
+	}
+
+	s := newSemantics(opts)
+	disjuncts, err := ToDNF(a, s.normal...)
+	if err != nil {
+		return Unknown
+	}
+	clauses, err := ToCNF(b, s.normal...)
+	if err != nil {
+		return Unknown
+	}
+
+	result := True
+	for _, d := range flatten(Or, disjuncts) {
+		terms := flatten(And, d)
+		cons, rest := constraints(terms, s)
+
+		// an unsatisfiable disjunct matches nothing so it implies anything
+		if unsatisfiableConj(terms, cons) {
+			continue
+		}
+		exact := len(rest) == 0
+
+		for _, c := range flatten(And, clauses) {
+			switch conjImpliesClause(terms, cons, flatten(Or, c), exact) {
+			case False:
+				return False
+			case Unknown:
+				result = Unknown
+			}
+		}
+	}
+	return result
+}
+
+func unsatisfiableConj(terms []*Expression, cons map[Column]*fieldConstraint) bool {
+	if complementPair(terms) != nil {
+		return true
+	}
+	for col, c := range cons {
+		if c.unsatisfiable(col) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// conjImpliesClause checks whether a conjunction implies a disjunction of leaves. Proving a single
+// leaf is enough. It is only False if the clause has one leaf that the conjunction provably doesn't
+// imply, since leaves over the same field could cover the conjunction together.
+func conjImpliesClause(terms []*Expression, cons map[Column]*fieldConstraint, clause []*Expression, exact bool) Tristate {
+	results := []Tristate{}
+	for _, lit := range clause {
+		r := conjImpliesLeaf(terms, cons, lit)
+		if r == True {
+			return True
+		}
+		results = append(results, r)
+	}
+
+	if len(results) == 1 && results[0] == False && exact {
+		return False
+	}
+	return Unknown
+}
+
+func conjImpliesLeaf(terms []*Expression, cons map[Column]*fieldConstraint, lit *Expression) Tristate {
+	for _, t := range terms {
+		if Equal(t, lit) {
+			return True
+		}
+	}
+
+	negated := false
+	leaf := lit
+	if lit.Op == Not && IsExpr(lit.Left) {
+		negated = true
+		leaf = lit.Left.(*Expression)
+	}
+
+	if col, vals, ok := inValues(leaf); ok {
+		c, found := cons[col]
+		if !found {
+			// nothing constrains the field so a matching document can have any value for it
+			return False
+		}
+		if !c.single(vals) {
+			return c.contained(vals, negated)
+		}
+		if negated {
+			return c.excludes(vals)
+		}
+		return c.within(vals)
+	}
+
+	if col, iv, ok := toInterval(leaf); ok && !negated {
+		c, found := cons[col]
+		if !found {
+			return False
+		}
+		return c.inside(iv)
+	}
+
+	return Unknown
+}
+
+// contained checks whether a field that isn't a keyword contains one of vals, or none of them
+// when negated. A field can contain several values so a value it contains doesn't rule out the
+// others.
+func (c *fieldConstraint) contained(vals []*Expression, negated bool) Tristate {
+	for _, set := range c.anyOf {
+		if c.allExcluded(set) || !subset(set, vals) {
+			continue
+		}
+		if negated {
+			return False
+		}
+		return True
+	}
+
+	if negated && c.allExcluded(vals) {
+		return True
+	}
+	return Unknown
+}
+
+func subset(vals, of []*Expression) bool {
+	for _, v := range vals {
+		if !containsValue(of, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// within checks whether every value the field can take is one of vals.
+func (c *fieldConstraint) within(vals []*Expression) Tristate {
+	if !c.comparable {
+		return Unknown
+	}
+
+	if !c.hasAllowed {
+		// only a single point interval is narrow enough
+		if c.iv.min.value != nil && c.iv.max.value != nil && c.iv.min.inclusive && c.iv.max.inclusive &&
+			sameValue(c.iv.min.value, c.iv.max.value) && containsValue(vals, c.iv.min.value) {
+			return True
+		}
+		return False
+	}
+
+	for _, v := range c.candidates() {
+		if !containsValue(vals, v) {
+			return False
+		}
+	}
+	return True
+}
+
+// excludes checks whether the field can never take any of vals.
+func (c *fieldConstraint) excludes(vals []*Expression) Tristate {
+	if !c.comparable {
+		return Unknown
+	}
+
+	if c.hasAllowed {
+		for _, v := range c.candidates() {
+			if containsValue(vals, v) {
+				return False
+			}
+		}
+		return True
+	}
+
+	for _, v := range vals {
+		if containsValue(c.excluded, v) {
+			continue
+		}
+		in, ok := c.iv.contains(v)
+		if !ok {
+			return Unknown
+		}
+		if in {
+			return False
+		}
+	}
+	return True
+}
+
+// inside checks whether every value the field can take falls in the interval.
+func (c *fieldConstraint) inside(iv interval) Tristate {
+	if !c.comparable {
+		return Unknown
+	}
+
+	if c.hasAllowed {
+		for _, v := range c.candidates() {
+			in, ok := iv.contains(v)
+			if !ok {
+				return Unknown
+			}
+			if !in {
+				return False
+			}
+		}
+		return True
+	}
+
+	if !sameKind(c.iv, iv) || (!c.keyword && !iv.numeric()) {
+		return Unknown
+	}
+	lower, lok := compareLower(iv.min, c.iv.min)
+	upper, uok := compareUpper(c.iv.max, iv.max)
+	if !lok || !uok {
+		return Unknown
+	}
+	if lower <= 0 && upper <= 0 {
+		return True
+	}
+	// the values a field that isn't a keyword contains don't bound it
+	if len(c.anyOf) > 0 {
+		return Unknown
+	}
+	return False
+}
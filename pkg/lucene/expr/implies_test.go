@@ -0,0 +1,147 @@
+package expr
+
+import (
+	"testing"
+)
+
+func TestImplies(t *testing.T) {
+	type tc struct {
+		a    *Expression
+		b    *Expression
+		opts []ConstraintOpt
+		want Tristate
+	}
+
+	tcs := map[string]tc{
+		"identical": {
+			a:    Eq("a", 1),
+			b:    Eq("a", 1),
+			want: True,
+		},
+		"narrower_conjunction": {
+			a:    AND(Eq("tenant", "acme"), Eq("status", 200)),
+			b:    Eq("tenant", "acme"),
+			want: True,
+		},
+		"wider_conjunction": {
+			a:    Eq("tenant", "acme"),
+			b:    AND(Eq("tenant", "acme"), Eq("status", 200)),
+			want: False,
+		},
+		"different_value": {
+			a:    Eq("tenant", "acme"),
+			b:    Eq("tenant", "other"),
+			opts: []ConstraintOpt{WithFieldKind("tenant", Keyword)},
+			want: False,
+		},
+		"equal_inside_in": {
+			a:    Eq("a", "x"),
+			b:    IN("a", LIST(Lit("x"), Lit("y"))),
+			want: True,
+		},
+		"in_inside_in": {
+			a:    IN("a", LIST(Lit("x"), Lit("y"))),
+			b:    IN("a", LIST(Lit("x"), Lit("y"), Lit("z"))),
+			want: True,
+		},
+		"in_outside_in": {
+			a:    IN("a", LIST(Lit("x"), Lit("w"))),
+			b:    IN("a", LIST(Lit("x"), Lit("y"))),
+			opts: []ConstraintOpt{WithFieldKind("a", Keyword)},
+			want: False,
+		},
+		"range_inside_range": {
+			a:    Rang("age", 12, 18, true),
+			b:    Rang("age", 10, 20, true),
+			want: True,
+		},
+		"range_overlapping_range": {
+			a:    Rang("age", 5, 15, true),
+			b:    Rang("age", 10, 20, true),
+			want: False,
+		},
+		"exclusive_bounds": {
+			a:    Rang("age", 10, 20, true),
+			b:    Rang("age", 10, 20, false),
+			want: False,
+		},
+		"comparisons": {
+			a:    AND(GREATER("age", 30), LESS("age", 40)),
+			b:    GREATEREQ("age", 30),
+			want: True,
+		},
+		"value_inside_range": {
+			a:    Eq("age", 15),
+			b:    Rang("age", 10, 20, true),
+			opts: []ConstraintOpt{WithFieldKind("age", Keyword)},
+			want: True,
+		},
+		"values_outside_negation": {
+			a:    IN("a", LIST(Lit("x"), Lit("y"))),
+			b:    NOT(Eq("a", "z")),
+			opts: []ConstraintOpt{WithFieldKind("a", Keyword)},
+			want: True,
+		},
+		"value_inside_negation": {
+			a:    Eq("a", "z"),
+			b:    NOT(Eq("a", "z")),
+			want: False,
+		},
+		"range_outside_negation": {
+			a:    GREATER("age", 30),
+			b:    NOT(Eq("age", 10)),
+			opts: []ConstraintOpt{WithFieldKind("age", Keyword)},
+			want: True,
+		},
+		"disjunction_inside_scope": {
+			a:    OR(Eq("tenant", "acme"), Eq("tenant", "globex")),
+			b:    IN("tenant", LIST(Lit("acme"), Lit("globex"))),
+			want: True,
+		},
+		"disjunction_escapes_scope": {
+			a:    OR(AND(Eq("tenant", "acme"), Eq("a", 1)), Eq("b", 2)),
+			b:    Eq("tenant", "acme"),
+			want: False,
+		},
+		"unsatisfiable_implies_anything": {
+			a:    AND(Eq("a", 1), Eq("a", 2)),
+			b:    Eq("b", 3),
+			opts: []ConstraintOpt{WithFieldKind("a", Keyword)},
+			want: True,
+		},
+		"contained_value_keeps_others": {
+			a:    Eq("a", "x"),
+			b:    NOT(Eq("a", "y")),
+			want: Unknown,
+		},
+		"contained_different_value": {
+			a:    Eq("tenant", "acme"),
+			b:    Eq("tenant", "other"),
+			want: Unknown,
+		},
+		"contained_excluded_value": {
+			a:    AND(Eq("a", "x"), NOT(Eq("a", "y"))),
+			b:    NOT(Eq("a", "y")),
+			want: True,
+		},
+		"unknown_for_wildcards": {
+			a:    LIKE("a", WILD("foo*")),
+			b:    LIKE("a", WILD("f*")),
+			want: Unknown,
+		},
+		"unknown_for_split_ranges": {
+			a:    Rang("age", 1, 10, true),
+			b:    OR(LESS("age", 5), GREATEREQ("age", 5)),
+			want: Unknown,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got := Implies(tc.a, tc.b, tc.opts...)
+			if got != tc.want {
+				t.Fatalf("Implies(%s, %s) = %s, want %s", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyClauses is returned when converting an expression to a normal form would produce more
+// clauses than the configured limit.
+var ErrTooManyClauses = errors.New("normal form exceeds the clause limit")
+
+const defaultMaxClauses = 1024
+
+type normalizer struct {
+	maxClauses int
+}
+
+// NormalFormOpt configures the conversion to a normal form
+type NormalFormOpt func(*normalizer)
+
+// WithMaxClauses limits the number of clauses a normal form may have. Distributing AND over OR
+// (or the reverse) can grow exponentially so the conversion stops with ErrTooManyClauses once the
+// limit is reached.
+func WithMaxClauses(max int) NormalFormOpt {
+	return func(n *normalizer) {
+		n.maxClauses = max
+	}
+}
+
+// clause is a flat list of leaves joined by the inner operator of the normal form
+type clause []*Expression
+
+// ToCNF converts the expression to conjunctive normal form: an AND of ORs over leaves and negated
+// leaves. Negations are pushed down with De Morgan's laws, MUST is treated as a plain requirement,
+// MUST_NOT as a negation and boosts are dropped since they only affect scoring. Ranges, IN lists
+// and the other comparisons are kept as leaves.
+func ToCNF(e *Expression, opts ...NormalFormOpt) (*Expression, error) {
+	return toNormalForm(e, And, Or, opts)
+}
+
+// ToDNF converts the expression to disjunctive normal form: an OR of ANDs over leaves and negated
+// leaves. It follows the same rules as ToCNF.
+func ToDNF(e *Expression, opts ...NormalFormOpt) (*Expression, error) {
+	return toNormalForm(e, Or, And, opts)
+}
+
+func toNormalForm(e *Expression, outer, inner Operator, opts []NormalFormOpt) (*Expression, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	n := &normalizer{maxClauses: defaultMaxClauses}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	clauses, err := n.clauses(toNNF(e, false), outer, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []*Expression{}
+	for _, c := range clauses {
+		terms = append(terms, chain(inner, c))
+	}
+	return chain(outer, terms), nil
+}
+
+// toNNF pushes all negations down to the leaves (negation normal form).
+func toNNF(e *Expression, negate bool) *Expression {
+	switch e.Op {
+	case Not, MustNot:
+		return toNNF(e.Left.(*Expression), !negate)
+	case Must, Boost:
+		return toNNF(e.Left.(*Expression), negate)
+	case Bool:
+		return toNNF(ToFilter(e), negate)
+	case And, Or:
+		op := e.Op
+		if negate {
+			// De Morgan: NOT (a AND b) = NOT a OR NOT b and NOT (a OR b) = NOT a AND NOT b
+			op = flip(op)
+		}
+		return Expr(
+			toNNF(e.Left.(*Expression), negate),
+			op,
+			toNNF(e.Right.(*Expression), negate),
+		)
+	default:
+		if negate {
+			return NOT(e)
+		}
+		return e
+	}
+}
+
+func flip(op Operator) Operator {
+	if op == And {
+		return Or
+	}
+	return And
+}
+
+// clauses distributes the expression (in negation normal form) into a list of clauses. Each clause
+// joins its leaves with the inner operator and the clauses are joined with the outer operator.
+func (n *normalizer) clauses(e *Expression, outer, inner Operator) ([]clause, error) {
+	switch e.Op {
+	case outer:
+		left, err := n.clauses(e.Left.(*Expression), outer, inner)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.clauses(e.Right.(*Expression), outer, inner)
+		if err != nil {
+			return nil, err
+		}
+		return n.limit(dedupeClauses(append(left, right...)))
+	case inner:
+		left, err := n.clauses(e.Left.(*Expression), outer, inner)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.clauses(e.Right.(*Expression), outer, inner)
+		if err != nil {
+			return nil, err
+		}
+
+		// check the size of the cross product before building it
+		if n.maxClauses > 0 && len(left)*len(right) > n.maxClauses {
+			return nil, fmt.Errorf("%w: %d clauses, limit is %d", ErrTooManyClauses, len(left)*len(right), n.maxClauses)
+		}
+
+		out := []clause{}
+		for _, l := range left {
+			for _, r := range right {
+				merged := append(append(clause{}, l...), r...)
+				out = append(out, clause(dedupe(merged)))
+			}
+		}
+		return n.limit(dedupeClauses(out))
+	default:
+		return []clause{{e}}, nil
+	}
+}
+
+func (n *normalizer) limit(clauses []clause) ([]clause, error) {
+	if n.maxClauses > 0 && len(clauses) > n.maxClauses {
+		return nil, fmt.Errorf("%w: %d clauses, limit is %d", ErrTooManyClauses, len(clauses), n.maxClauses)
+	}
+	return clauses, nil
+}
+
+func dedupeClauses(clauses []clause) []clause {
+	out := []clause{}
+	seen := map[string]bool{}
+	for _, c := range clauses {
+		k := key(Canonical(chain(And, c)))
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, c)
+	}
+	return out
+}
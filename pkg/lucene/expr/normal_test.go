@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalForms(t *testing.T) {
+	type tc struct {
+		input   *Expression
+		wantCNF *Expression
+		wantDNF *Expression
+	}
+
+	a, b, c, d := Eq("a", 1), Eq("b", 2), Eq("c", 3), Eq("d", 4)
+
+	tcs := map[string]tc{
+		"leaf": {
+			input:   a,
+			wantCNF: a,
+			wantDNF: a,
+		},
+		"or_of_ands": {
+			input:   OR(AND(a, b), AND(c, d)),
+			wantCNF: AND(AND(AND(OR(a, c), OR(a, d)), OR(b, c)), OR(b, d)),
+			wantDNF: OR(AND(a, b), AND(c, d)),
+		},
+		"and_of_ors": {
+			input:   AND(OR(a, b), c),
+			wantCNF: AND(OR(a, b), c),
+			wantDNF: OR(AND(a, c), AND(b, c)),
+		},
+		"de_morgan_and": {
+			input:   NOT(AND(a, b)),
+			wantCNF: OR(NOT(a), NOT(b)),
+			wantDNF: OR(NOT(a), NOT(b)),
+		},
+		"de_morgan_or": {
+			input:   NOT(OR(a, b)),
+			wantCNF: AND(NOT(a), NOT(b)),
+			wantDNF: AND(NOT(a), NOT(b)),
+		},
+		"double_negation": {
+			input:   NOT(NOT(a)),
+			wantCNF: a,
+			wantDNF: a,
+		},
+		"must_and_must_not": {
+			input:   AND(MUST(a), MUSTNOT(OR(b, c))),
+			wantCNF: AND(AND(a, NOT(b)), NOT(c)),
+			wantDNF: AND(AND(a, NOT(b)), NOT(c)),
+		},
+		"range_and_in_are_leaves": {
+			input:   NOT(AND(Rang("a", 1, 5, true), IN("b", LIST(Lit("x"), Lit("y"))))),
+			wantCNF: OR(NOT(Rang("a", 1, 5, true)), NOT(IN("b", LIST(Lit("x"), Lit("y"))))),
+			wantDNF: OR(NOT(Rang("a", 1, 5, true)), NOT(IN("b", LIST(Lit("x"), Lit("y"))))),
+		},
+		"boost_is_dropped": {
+			input:   BOOST(OR(a, b), 2),
+			wantCNF: OR(a, b),
+			wantDNF: OR(a, b),
+		},
+		"duplicate_leaves": {
+			input:   AND(OR(a, a), a),
+			wantCNF: a,
+			wantDNF: a,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := ToCNF(tc.input)
+			if err != nil {
+				t.Fatalf("expected no error converting to CNF but got [%s]", err)
+			}
+			if !sameExpr(got, tc.wantCNF) {
+				t.Fatalf(errTemplate, "CNF doesn't match", tc.wantCNF, got)
+			}
+
+			got, err = ToDNF(tc.input)
+			if err != nil {
+				t.Fatalf("expected no error converting to DNF but got [%s]", err)
+			}
+			if !sameExpr(got, tc.wantDNF) {
+				t.Fatalf(errTemplate, "DNF doesn't match", tc.wantDNF, got)
+			}
+		})
+	}
+}
+
+func TestNormalFormClauseLimit(t *testing.T) {
+	// (a1 AND b1) OR (a2 AND b2) OR ... has 2^n clauses in CNF
+	var e *Expression
+	for i := 0; i < 12; i++ {
+		term := AND(Eq("a", i), Eq("b", i))
+		if e == nil {
+			e = term
+			continue
+		}
+		e = OR(e, term)
+	}
+
+	_, err := ToCNF(e, WithMaxClauses(100))
+	if !errors.Is(err, ErrTooManyClauses) {
+		t.Fatalf("expected ErrTooManyClauses but got [%v]", err)
+	}
+
+	got, err := ToDNF(e, WithMaxClauses(100))
+	if err != nil {
+		t.Fatalf("expected no error converting to DNF but got [%s]", err)
+	}
+	if !Equal(got, e) {
+		t.Fatalf(errTemplate, "DNF doesn't match", e, got)
+	}
+}
@@ -27,6 +27,7 @@ var renderers = map[Operator]renderer{
 	Like:      renderBasic,
 	In:        renderBasic,
 	List:      renderList,
+	Bool:      renderBool,
 }
 
 func renderEquals(e *Expression, verbose bool) string {
@@ -142,3 +143,57 @@ func renderLiteral(e *Expression, verbose bool) string {
 
 	return fmt.Sprintf("%v", e.Left)
 }
+
+// renderBool renders the clauses with their lucene prefixes. Lucene has no syntax for a
+// minimum_should_match so when one changes which SHOULD clauses are required they are rendered as
+// a required group of the equivalent filter instead.
+func renderBool(e *Expression, verbose bool) string {
+	clauses := e.Clauses()
+	if verbose {
+		strs := []string{}
+		for _, c := range clauses {
+			strs = append(strs, fmt.Sprintf("%s(%#v)", c.Occur, c.Expr))
+		}
+		if e.minShouldMatch > 0 {
+			strs = append(strs, fmt.Sprintf("MINIMUM_SHOULD_MATCH(%d)", e.minShouldMatch))
+		}
+		return fmt.Sprintf("%s(%s)", toString[e.Op], strings.Join(strs, ", "))
+	}
+
+	musts, shoulds := 0, []*Expression{}
+	for _, c := range clauses {
+		switch c.Occur {
+		case OccurMust:
+			musts++
+		case OccurShould:
+			shoulds = append(shoulds, c.Expr)
+		}
+	}
+	native := e.minShouldMatch == 0 || (musts == 0 && e.minShouldMatch <= 1)
+
+	strs := []string{}
+	for _, c := range clauses {
+		switch {
+		case c.Occur == OccurMust:
+			strs = append(strs, "+"+renderClause(c.Expr))
+		case c.Occur == OccurMustNot:
+			strs = append(strs, "-"+renderClause(c.Expr))
+		case native:
+			strs = append(strs, renderClause(c.Expr))
+		}
+	}
+	if !native {
+		strs = append(strs, fmt.Sprintf("+(%s)", atLeast(e.RequiredShould(), shoulds)))
+	}
+	return strings.Join(strs, " ")
+}
+
+// renderClause wraps clauses made of several terms in parens so they stay a single clause
+func renderClause(e *Expression) string {
+	switch e.Op {
+	case And, Or, Bool, Greater, Less, GreaterEq, LessEq, Like, In:
+		return fmt.Sprintf("(%s)", e)
+	default:
+		return fmt.Sprintf("%s", e)
+	}
+}
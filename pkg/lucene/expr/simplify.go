@@ -0,0 +1,558 @@
+package expr
+
+import (
+	"strings"
+)
+
+// Simplify returns a simplified copy of the expression. It flattens nested AND/OR chains, removes
+// double negations, folds MUST_NOT into NOT, dedupes identical sibling clauses, collapses OR'd
+// equalities on the same field into an IN list and merges overlapping ranges on the same field.
+// The input expression is not modified.
+func Simplify(e *Expression) *Expression {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case And, Or:
+		return simplifyCompound(e)
+	case Not, MustNot:
+		sub := simplifyAny(e.Left)
+		// NOT NOT a is just a
+		if inner, isExpr := sub.(*Expression); isExpr && inner.Op == Not {
+			return inner.Left.(*Expression)
+		}
+		return NOT(sub)
+	case Bool:
+		return Simplify(ToFilter(e))
+	case Literal, Wild, Regexp, List, Equals, Like, In, Range, Greater, Less, GreaterEq, LessEq:
+		return e
+	default:
+		// keep the operator specific state (boost power, fuzzy distance) by copying the node
+		out := *e
+		out.Left = simplifyAny(e.Left)
+		out.Right = simplifyAny(e.Right)
+		return &out
+	}
+}
+
+func simplifyAny(in any) any {
+	e, isExpr := in.(*Expression)
+	if !isExpr {
+		return in
+	}
+	return Simplify(e)
+}
+
+func simplifyCompound(e *Expression) *Expression {
+	terms := []*Expression{}
+	for _, t := range flatten(e.Op, e) {
+		terms = append(terms, Simplify(t))
+	}
+
+	// simplifying the children can surface new chains of the same operator (e.g. a double negation
+	// around an OR) so flatten once more
+	flat := []*Expression{}
+	for _, t := range terms {
+		flat = append(flat, flatten(e.Op, t)...)
+	}
+
+	if e.Op == Or {
+		flat = collapseIn(flat)
+	}
+	flat = mergeRanges(e.Op, flat)
+	flat = dedupe(flat)
+
+	return chain(e.Op, flat)
+}
+
+// flatten collects the operands of a chain of the same compound operator.
+func flatten(op Operator, e *Expression) []*Expression {
+	if e == nil {
+		return nil
+	}
+	if e.Op != op {
+		return []*Expression{e}
+	}
+
+	out := []*Expression{}
+	for _, side := range []any{e.Left, e.Right} {
+		sub, isExpr := side.(*Expression)
+		if !isExpr {
+			continue
+		}
+		out = append(out, flatten(op, sub)...)
+	}
+	return out
+}
+
+// chain rebuilds a left deep chain of the compound operator, which is the same shape the parser
+// produces for a AND b AND c.
+func chain(op Operator, terms []*Expression) *Expression {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	out := terms[0]
+	for _, t := range terms[1:] {
+		out = Expr(out, op, t)
+	}
+	return out
+}
+
+func dedupe(terms []*Expression) []*Expression {
+	out := []*Expression{}
+	for _, t := range terms {
+		if !containsExpr(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func containsExpr(list []*Expression, e *Expression) bool {
+	for _, v := range list {
+		if sameExpr(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseIn merges the OR'd equalities and IN lists over the same field into a single IN list.
+// The merged list takes the place of the first clause for the field.
+func collapseIn(terms []*Expression) []*Expression {
+	values := map[Column][]*Expression{}
+	counts := map[Column]int{}
+	for _, t := range terms {
+		col, vals, ok := inValues(t)
+		if !ok {
+			continue
+		}
+		counts[col]++
+		for _, v := range vals {
+			if !containsExpr(values[col], v) {
+				values[col] = append(values[col], v)
+			}
+		}
+	}
+
+	out := []*Expression{}
+	seen := map[Column]bool{}
+	for _, t := range terms {
+		col, _, ok := inValues(t)
+		if !ok || counts[col] < 2 {
+			out = append(out, t)
+			continue
+		}
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+
+		if len(values[col]) == 1 {
+			out = append(out, Eq(Lit(col), values[col][0]))
+			continue
+		}
+		out = append(out, IN(Lit(col), LIST(values[col])))
+	}
+	return out
+}
+
+// inValues returns the column and the literal values matched by an equality or IN clause.
+func inValues(e *Expression) (col Column, vals []*Expression, ok bool) {
+	col, ok = columnOf(e)
+	if !ok {
+		return col, nil, false
+	}
+
+	right, isExpr := e.Right.(*Expression)
+	if !isExpr {
+		return col, nil, false
+	}
+
+	switch {
+	case e.Op == Equals && right.Op == Literal:
+		return col, []*Expression{right}, true
+	case e.Op == In && right.Op == List:
+		list, isList := right.Left.([]*Expression)
+		return col, list, isList
+	default:
+		return col, nil, false
+	}
+}
+
+// columnOf returns the column a leaf expression operates on.
+func columnOf(e *Expression) (col Column, ok bool) {
+	if e == nil || !operatesOnColumn(e.Op) {
+		return col, false
+	}
+
+	left, isExpr := e.Left.(*Expression)
+	if !isExpr || left.Op != Literal {
+		return col, false
+	}
+
+	col, ok = left.Left.(Column)
+	return col, ok
+}
+
+// bound is one side of an interval. A nil value means the side is unbounded.
+type bound struct {
+	value     *Expression
+	inclusive bool
+}
+
+type interval struct {
+	min bound
+	max bound
+}
+
+// toInterval converts a range or comparison over a column into an interval.
+func toInterval(e *Expression) (col Column, iv interval, ok bool) {
+	col, ok = columnOf(e)
+	if !ok {
+		return col, iv, false
+	}
+
+	if e.Op == Range {
+		boundary, isBoundary := e.Right.(*RangeBoundary)
+		if !isBoundary {
+			return col, iv, false
+		}
+		min, minOK := boundValue(boundary.Min)
+		max, maxOK := boundValue(boundary.Max)
+		if !minOK || !maxOK {
+			return col, iv, false
+		}
+		iv.min = bound{value: min, inclusive: boundary.Inclusive}
+		iv.max = bound{value: max, inclusive: boundary.Inclusive}
+		return col, iv, true
+	}
+
+	if !isComparison(e.Op) {
+		return col, iv, false
+	}
+
+	val, isOK := boundValue(e.Right)
+	if !isOK || val == nil {
+		return col, iv, false
+	}
+
+	switch e.Op {
+	case Greater:
+		iv.min = bound{value: val}
+	case GreaterEq:
+		iv.min = bound{value: val, inclusive: true}
+	case Less:
+		iv.max = bound{value: val}
+	case LessEq:
+		iv.max = bound{value: val, inclusive: true}
+	}
+	return col, iv, true
+}
+
+// boundValue unwraps a range boundary value. The * wildcard is returned as a nil (unbounded) value.
+func boundValue(in any) (val *Expression, ok bool) {
+	e, isExpr := in.(*Expression)
+	if !isExpr {
+		return nil, false
+	}
+	if e.Op == Wild && e.Left == "*" {
+		return nil, true
+	}
+	if e.Op != Literal || !(isNum(e.Left) || isString(e.Left)) {
+		return nil, false
+	}
+	return e, true
+}
+
+// compareValues orders two literal values. Numbers are compared numerically and strings
+// lexicographically. Mixed types can't be compared.
+func compareValues(a, b any) (cmp int, ok bool) {
+	if isNum(a) && isNum(b) {
+		fa, fb := toFloat(a), toFloat(b)
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	sa, aIsStr := a.(string)
+	sb, bIsStr := b.(string)
+	if aIsStr && bIsStr {
+		return strings.Compare(sa, sb), true
+	}
+	return 0, false
+}
+
+func toFloat(in any) float64 {
+	switch v := in.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
+	}
+}
+
+// compareLower orders two lower bounds, treating an unbounded side as the smallest.
+func compareLower(a, b bound) (cmp int, ok bool) {
+	switch {
+	case a.value == nil && b.value == nil:
+		return 0, true
+	case a.value == nil:
+		return -1, true
+	case b.value == nil:
+		return 1, true
+	}
+
+	cmp, ok = compareValues(a.value.Left, b.value.Left)
+	if !ok || cmp != 0 {
+		return cmp, ok
+	}
+	// an inclusive lower bound starts before an exclusive one on the same value
+	switch {
+	case a.inclusive == b.inclusive:
+		return 0, true
+	case a.inclusive:
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// compareUpper orders two upper bounds, treating an unbounded side as the largest.
+func compareUpper(a, b bound) (cmp int, ok bool) {
+	switch {
+	case a.value == nil && b.value == nil:
+		return 0, true
+	case a.value == nil:
+		return 1, true
+	case b.value == nil:
+		return -1, true
+	}
+
+	cmp, ok = compareValues(a.value.Left, b.value.Left)
+	if !ok || cmp != 0 {
+		return cmp, ok
+	}
+	// an inclusive upper bound ends after an exclusive one on the same value
+	switch {
+	case a.inclusive == b.inclusive:
+		return 0, true
+	case a.inclusive:
+		return 1, true
+	default:
+		return -1, true
+	}
+}
+
+// intersect returns the overlap of two intervals. It is not ok if the bounds can't be compared.
+func intersect(a, b interval) (out interval, ok bool) {
+	if !sameKind(a, b) {
+		return out, false
+	}
+
+	lower, ok := compareLower(a.min, b.min)
+	if !ok {
+		return out, false
+	}
+	upper, ok := compareUpper(a.max, b.max)
+	if !ok {
+		return out, false
+	}
+
+	out.min = a.min
+	if lower < 0 {
+		out.min = b.min
+	}
+	out.max = a.max
+	if upper > 0 {
+		out.max = b.max
+	}
+	return out, true
+}
+
+// union returns the interval covering both intervals if they overlap or touch.
+func union(a, b interval) (out interval, ok bool) {
+	if !sameKind(a, b) || !overlaps(a, b) {
+		return out, false
+	}
+
+	lower, ok := compareLower(a.min, b.min)
+	if !ok {
+		return out, false
+	}
+	upper, ok := compareUpper(a.max, b.max)
+	if !ok {
+		return out, false
+	}
+
+	out.min = a.min
+	if lower > 0 {
+		out.min = b.min
+	}
+	out.max = a.max
+	if upper < 0 {
+		out.max = b.max
+	}
+	return out, true
+}
+
+// overlaps checks whether two intervals share at least one point or touch at an inclusive bound.
+func overlaps(a, b interval) bool {
+	return reaches(a.max, b.min) && reaches(b.max, a.min)
+}
+
+// reaches checks whether an upper bound is at or past a lower bound.
+func reaches(upper, lower bound) bool {
+	if upper.value == nil || lower.value == nil {
+		return true
+	}
+	cmp, ok := compareValues(upper.value.Left, lower.value.Left)
+	if !ok {
+		return false
+	}
+	if cmp == 0 {
+		return upper.inclusive || lower.inclusive
+	}
+	return cmp > 0
+}
+
+// sameKind checks that every bound of both intervals can be compared with each other so we
+// never merge a numeric range with a string range.
+func sameKind(a, b interval) bool {
+	vals := []*Expression{}
+	for _, bd := range []bound{a.min, a.max, b.min, b.max} {
+		if bd.value != nil {
+			vals = append(vals, bd.value)
+		}
+	}
+	if len(vals) < 2 {
+		return true
+	}
+	for _, v := range vals[1:] {
+		if _, ok := compareValues(vals[0].Left, v.Left); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmpty checks whether no value can fall inside the interval.
+func (iv interval) isEmpty() bool {
+	if iv.min.value == nil || iv.max.value == nil {
+		return false
+	}
+	cmp, ok := compareValues(iv.min.value.Left, iv.max.value.Left)
+	if !ok {
+		return false
+	}
+	if cmp == 0 {
+		return !(iv.min.inclusive && iv.max.inclusive)
+	}
+	return cmp > 0
+}
+
+// toExpr renders the interval back into the smallest expression for the column.
+func (iv interval) toExpr(col Column) *Expression {
+	switch {
+	case iv.min.value == nil && iv.max.value == nil:
+		return nil
+	case iv.min.value == nil && iv.max.inclusive:
+		return LESSEQ(Lit(col), iv.max.value)
+	case iv.min.value == nil:
+		return LESS(Lit(col), iv.max.value)
+	case iv.max.value == nil && iv.min.inclusive:
+		return GREATEREQ(Lit(col), iv.min.value)
+	case iv.max.value == nil:
+		return GREATER(Lit(col), iv.min.value)
+	case iv.min.inclusive == iv.max.inclusive:
+		return Rang(Lit(col), iv.min.value, iv.max.value, iv.min.inclusive)
+	default:
+		// a range boundary only has one inclusive flag so mixed bounds need two comparisons
+		return AND(
+			interval{min: iv.min}.toExpr(col),
+			interval{max: iv.max}.toExpr(col),
+		)
+	}
+}
+
+// mergeRanges merges the ranges and comparisons over the same field. AND'd ranges are
+// intersected and OR'd ranges that overlap are unioned. The merged range takes the place
+// of the first clause for the field.
+func mergeRanges(op Operator, terms []*Expression) []*Expression {
+	merged := map[Column]interval{}
+	counts := map[Column]int{}
+	invalid := map[Column]bool{}
+	for _, t := range terms {
+		col, iv, ok := toInterval(t)
+		if !ok {
+			continue
+		}
+		counts[col]++
+
+		prev, seen := merged[col]
+		if !seen {
+			merged[col] = iv
+			continue
+		}
+
+		var next interval
+		if op == And {
+			next, ok = intersect(prev, iv)
+		} else {
+			next, ok = union(prev, iv)
+		}
+		if !ok {
+			invalid[col] = true
+			continue
+		}
+		merged[col] = next
+	}
+
+	out := []*Expression{}
+	seen := map[Column]bool{}
+	for _, t := range terms {
+		col, _, ok := toInterval(t)
+		iv := merged[col]
+		// leave the clauses alone if they can't be merged. Empty intersections are left for
+		// the caller to report rather than silently rewritten.
+		if !ok || counts[col] < 2 || invalid[col] || iv.isEmpty() || iv.toExpr(col) == nil {
+			out = append(out, t)
+			continue
+		}
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+		out = append(out, iv.toExpr(col))
+	}
+	return out
+}
+
+func isComparison(op Operator) bool {
+	return op == Greater || op == Less || op == GreaterEq || op == LessEq
+}
@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	type tc struct {
+		input *Expression
+		want  *Expression
+	}
+
+	tcs := map[string]tc{
+		"leaf_is_unchanged": {
+			input: Eq("a", "b"),
+			want:  Eq("a", "b"),
+		},
+		"flatten_and_chain": {
+			input: AND(Eq("a", "b"), AND(Eq("c", "d"), AND(Eq("e", "f"), Eq("g", "h")))),
+			want:  AND(AND(AND(Eq("a", "b"), Eq("c", "d")), Eq("e", "f")), Eq("g", "h")),
+		},
+		"double_negation": {
+			input: NOT(NOT(Eq("a", "b"))),
+			want:  Eq("a", "b"),
+		},
+		"must_not_folds_into_not": {
+			input: MUSTNOT(Eq("a", "b")),
+			want:  NOT(Eq("a", "b")),
+		},
+		"not_must_not_cancels": {
+			input: NOT(MUSTNOT(Eq("a", "b"))),
+			want:  Eq("a", "b"),
+		},
+		"dedupe_siblings": {
+			input: AND(Eq("a", "b"), AND(Eq("c", "d"), Eq("a", "b"))),
+			want:  AND(Eq("a", "b"), Eq("c", "d")),
+		},
+		"dedupe_to_single_clause": {
+			input: OR(Eq("a", "b"), Eq("a", "b")),
+			want:  Eq("a", "b"),
+		},
+		"collapse_or_into_in": {
+			input: OR(Eq("a", "x"), Eq("a", "y")),
+			want:  IN("a", LIST(Lit("x"), Lit("y"))),
+		},
+		"collapse_or_into_in_across_nesting": {
+			input: OR(Eq("a", "x"), OR(Eq("b", "z"), OR(Eq("a", "y"), IN("a", LIST(Lit("x"), Lit("w")))))),
+			want:  OR(IN("a", LIST(Lit("x"), Lit("y"), Lit("w"))), Eq("b", "z")),
+		},
+		"collapse_inside_and": {
+			input: AND(OR(Eq("a", "x"), Eq("a", "y")), Eq("b", "z")),
+			want:  AND(IN("a", LIST(Lit("x"), Lit("y"))), Eq("b", "z")),
+		},
+		"and_does_not_collapse_into_in": {
+			input: AND(Eq("a", "x"), Eq("a", "y")),
+			want:  AND(Eq("a", "x"), Eq("a", "y")),
+		},
+		"intersect_ranges": {
+			input: AND(Rang("a", 1, 10, true), Rang("a", 5, 20, true)),
+			want:  Rang("a", 5, 10, true),
+		},
+		"intersect_range_and_comparison": {
+			input: AND(GREATER("a", 3), AND(Eq("b", "c"), LESSEQ("a", 7))),
+			want:  AND(AND(GREATER("a", 3), LESSEQ("a", 7)), Eq("b", "c")),
+		},
+		"intersect_unbound_range": {
+			input: AND(Rang("a", "*", 10, false), GREATER("a", 2)),
+			want:  Rang("a", 2, 10, false),
+		},
+		"union_overlapping_ranges": {
+			input: OR(Rang("a", 1, 5, true), Rang("a", 3, 9, true)),
+			want:  Rang("a", 1, 9, true),
+		},
+		"union_touching_ranges": {
+			input: OR(Rang("a", 1, 5, true), GREATER("a", 5)),
+			want:  GREATEREQ("a", 1),
+		},
+		"disjoint_ranges_are_kept": {
+			input: OR(Rang("a", 1, 2, true), Rang("a", 5, 9, true)),
+			want:  OR(Rang("a", 1, 2, true), Rang("a", 5, 9, true)),
+		},
+		"empty_intersection_is_kept": {
+			input: AND(GREATER("a", 10), LESS("a", 5)),
+			want:  AND(GREATER("a", 10), LESS("a", 5)),
+		},
+		"mixed_types_are_kept": {
+			input: AND(GREATER("a", 10), LESS("a", "z")),
+			want:  AND(GREATER("a", 10), LESS("a", "z")),
+		},
+		"keeps_boost_power": {
+			input: BOOST(NOT(NOT(Eq("a", "b"))), 2.5),
+			want:  BOOST(Eq("a", "b"), 2.5),
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			before := tc.input.GoString()
+			got := Simplify(tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf(errTemplate, "simplified expression doesn't match", tc.want, got)
+			}
+
+			if tc.input.GoString() != before {
+				t.Fatalf("input expression was modified: %#v", tc.input)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ var validators = map[Operator]validator{
 	Like:      validateLike,
 	In:        validateIn,
 	List:      validateList,
+	Bool:      validateBool,
 }
 
 func validateEquals(e *Expression) (err error) {
@@ -349,6 +350,50 @@ func validateList(e *Expression) (err error) {
 	return nil
 }
 
+func validateBool(e *Expression) (err error) {
+	if e == nil {
+		return nil
+	}
+
+	clauses, isClauses := e.Left.([]*BooleanClause)
+	if !isClauses || len(clauses) == 0 {
+		return errors.New("BOOL validation: must have at least one clause")
+	}
+
+	if e.Right != nil {
+		return errors.New("BOOL validation: right value must be nil")
+	}
+
+	shoulds := 0
+	for _, c := range clauses {
+		if c == nil || c.Expr == nil {
+			return errors.New("BOOL validation: clauses must not be nil")
+		}
+		if _, found := occurToString[c.Occur]; !found {
+			return fmt.Errorf("BOOL validation: unsupported occur type %d", c.Occur)
+		}
+		if c.Occur == OccurShould {
+			shoulds++
+		}
+	}
+
+	msm := e.minShouldMatch
+	if msm < 0 {
+		return fmt.Errorf("BOOL validation: minimum_should_match must not be negative, got %d", msm)
+	}
+	if msm > shoulds {
+		return fmt.Errorf("BOOL validation: minimum_should_match %d is more than the %d SHOULD clauses", msm, shoulds)
+	}
+	if msm > 1 && msm < shoulds && binomial(shoulds, msm, maxBoolCombinations) > maxBoolCombinations {
+		return fmt.Errorf(
+			"BOOL validation: minimum_should_match %d of %d SHOULD clauses has more than %d combinations",
+			msm, shoulds, maxBoolCombinations,
+		)
+	}
+
+	return nil
+}
+
 func isListOfLiteralExprs(in any) bool {
 	e, isList := in.([]*Expression)
 	if !isList {
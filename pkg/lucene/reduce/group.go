@@ -0,0 +1,105 @@
+package reduce
+
+import (
+	"github.com/AlxBystrov/go-lucene/internal/lex"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// ImplicitAnd is the AND the parser injects between clauses that are next to each other. Its empty
+// value tells it apart from an AND written in the query.
+var ImplicitAnd = lex.Token{Typ: lex.TAnd}
+
+// group is kept in the right side of a BOOL expression while the parser can still add clauses
+// to it. It holds the AND chain the clauses turn into if none of them are prefixed.
+type group struct {
+	conjunction *expr.Expression
+}
+
+// joinGroups adds two clauses next to each other to the same boolean group.
+func joinGroups(left, right *expr.Expression, defaultField string) *expr.Expression {
+	clauses := append(clausesOf(left, defaultField), clausesOf(right, defaultField)...)
+	b := expr.BOOL(clauses)
+	b.Right = group{
+		conjunction: expr.AND(conjunctionOf(left, defaultField), conjunctionOf(right, defaultField)),
+	}
+	return b
+}
+
+func openGroup(e *expr.Expression) (group, bool) {
+	if e == nil || e.Op != expr.Bool {
+		return group{}, false
+	}
+	g, isOpen := e.Right.(group)
+	return g, isOpen
+}
+
+func clausesOf(e *expr.Expression, defaultField string) []*expr.BooleanClause {
+	if _, isOpen := openGroup(e); isOpen {
+		return e.Clauses()
+	}
+
+	// a boost binds tighter than the prefix (+a^2) but the prefix still decides how the clause occurs
+	if inner, isExpr := e.Left.(*expr.Expression); e.Op == expr.Boost && isExpr &&
+		(inner.Op == expr.Must || inner.Op == expr.MustNot) {
+		boosted := *e
+		boosted.Left = inner.Left
+		prefixed := *inner
+		prefixed.Left = &boosted
+		return clausesOf(&prefixed, defaultField)
+	}
+
+	switch e.Op {
+	case expr.Must:
+		return []*expr.BooleanClause{expr.Clause(expr.OccurMust, wrapLiteral(e.Left.(*expr.Expression), defaultField))}
+	case expr.MustNot:
+		return []*expr.BooleanClause{expr.Clause(expr.OccurMustNot, wrapLiteral(e.Left.(*expr.Expression), defaultField))}
+	default:
+		return []*expr.BooleanClause{expr.Clause(expr.OccurShould, wrapLiteral(e, defaultField))}
+	}
+}
+
+func conjunctionOf(e *expr.Expression, defaultField string) *expr.Expression {
+	if g, isOpen := openGroup(e); isOpen {
+		return g.conjunction
+	}
+	return wrapLiteral(e, defaultField)
+}
+
+// Close ends the boolean groups of a parsed expression. A group with a clause prefixed by + or - is
+// a lucene boolean query: the prefixed clauses are required or excluded and the others are SHOULD
+// clauses. Any other group is the implicit AND chain of its clauses. A minimumShouldMatch above 0
+// turns a group at the root of the expression into a boolean query with that minimum_should_match
+// even if none of its clauses are prefixed.
+func Close(e *expr.Expression, minimumShouldMatch int) *expr.Expression {
+	if e == nil {
+		return nil
+	}
+
+	if g, isOpen := openGroup(e); isOpen {
+		prefixed := false
+		clauses := []*expr.BooleanClause{}
+		for _, c := range e.Clauses() {
+			prefixed = prefixed || c.Occur != expr.OccurShould
+			clauses = append(clauses, expr.Clause(c.Occur, Close(c.Expr, 0)))
+		}
+		if !prefixed && minimumShouldMatch <= 0 {
+			return Close(g.conjunction, 0)
+		}
+		return expr.BOOL(clauses, minimumShouldMatch)
+	}
+
+	switch e.Op {
+	case expr.And, expr.Or, expr.Not, expr.Must, expr.MustNot, expr.Boost, expr.Fuzzy:
+		// copy the expression so the boost power and fuzzy distance are kept
+		out := *e
+		if left, isExpr := e.Left.(*expr.Expression); isExpr {
+			out.Left = Close(left, 0)
+		}
+		if right, isExpr := e.Right.(*expr.Expression); isExpr {
+			out.Right = Close(right, 0)
+		}
+		return &out
+	default:
+		return e
+	}
+}
@@ -227,6 +227,13 @@ func and(elems []any, nonTerminals []lex.Token, defaultField string) ([]any, []l
 		return elems, nonTerminals, false
 	}
 
+	// clauses next to each other are collected into a boolean group which is only turned into an
+	// AND chain once we know none of its clauses are prefixed with + or -
+	if operatorToken == ImplicitAnd {
+		elems = []any{joinGroups(left, right, defaultField)}
+		return elems, drop(nonTerminals, 1), true
+	}
+
 	// we have a valid AND clause. Replace it in the stack
 	elems = []any{
 		expr.AND(
@@ -314,6 +321,11 @@ func sub(elems []any, nonTerminals []lex.Token, defaultField string) ([]any, []l
 		return elems, nonTerminals, false
 	}
 
+	// the parens end any boolean group inside of them
+	if inner, ok := elems[1].(*expr.Expression); ok {
+		return []any{Close(inner, 0)}, drop(nonTerminals, 2), true
+	}
+
 	// we consumed two terminals, the ( and )
 	return []any{elems[1]}, drop(nonTerminals, 2), true
 }
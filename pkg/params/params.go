@@ -0,0 +1,25 @@
+package params
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Placeholder returns the bind parameter of the nth argument, counting from 1
+type Placeholder func(n int) string
+
+// Question is the ? placeholder of MySQL and SQLite
+func Question(n int) string {
+	return "?"
+}
+
+// Numbered is the $1 placeholder of PostgreSQL
+func Numbered(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// ClickHouse is the {p1:String} query parameter of ClickHouse. The arguments are the values of
+// the parameters p1, p2... in order.
+func ClickHouse(n int) string {
+	return fmt.Sprintf("{p%d:String}", n)
+}
@@ -0,0 +1,38 @@
+package params
+
+import "testing"
+
+func TestPlaceholder(t *testing.T) {
+	type tc struct {
+		placeholder Placeholder
+		n           int
+		want        string
+	}
+
+	tcs := map[string]tc{
+		"question": {
+			placeholder: Question,
+			n:           2,
+			want:        "?",
+		},
+		"numbered": {
+			placeholder: Numbered,
+			n:           2,
+			want:        "$2",
+		},
+		"clickhouse": {
+			placeholder: ClickHouse,
+			n:           2,
+			want:        "{p2:String}",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got := tc.placeholder(tc.n)
+			if got != tc.want {
+				t.Fatalf("expected %q but got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package percolate
+
+import (
+	"fmt"
+
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// key is something a document has to contain for a query to be able to match it. A key with an
+// empty term only requires the field to be present, otherwise the field must have the exact value.
+type key struct {
+	field string
+	term  string
+}
+
+// requirement is a set of keys of which a document must contain at least one. A nil requirement
+// can't be indexed and the query is a candidate for every document.
+type requirement []key
+
+// extract works out the keys a document must contain for the expression to match it. Equality is
+// a substring match in eval so it can only require the field. IN lists match exactly and require
+// one of their values.
+func extract(e *expr.Expression) requirement {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Op {
+	case expr.And:
+		left, right := sub(e.Left), sub(e.Right)
+		return selective(extract(left), extract(right))
+	case expr.Or:
+		left, right := extract(sub(e.Left)), extract(sub(e.Right))
+		if left == nil || right == nil {
+			return nil
+		}
+		return append(append(requirement{}, left...), right...)
+	case expr.Must, expr.Boost:
+		return extract(sub(e.Left))
+	case expr.Bool:
+		// optional clauses don't change which documents match so only the filter counts
+		return extract(expr.ToFilter(e))
+	case expr.Fuzzy:
+		return extract(sub(e.Left))
+	case expr.In:
+		field, ok := fieldOf(e)
+		if !ok {
+			return nil
+		}
+		list := sub(e.Right)
+		if list == nil {
+			return requirement{{field: field}}
+		}
+		vals, isList := list.Left.([]*expr.Expression)
+		if !isList {
+			return requirement{{field: field}}
+		}
+		req := requirement{}
+		for _, v := range vals {
+			s, isStr := v.Left.(string)
+			if !isStr {
+				// numbers and booleans are compared by value and can't be looked up by their text
+				return requirement{{field: field}}
+			}
+			req = append(req, key{field: field, term: s})
+		}
+		return req
+	case expr.Equals, expr.Like, expr.Range, expr.Greater, expr.GreaterEq, expr.Less, expr.LessEq:
+		field, ok := fieldOf(e)
+		if !ok {
+			return nil
+		}
+		return requirement{{field: field}}
+	default:
+		// NOT can't require anything and bare terms search every field
+		return nil
+	}
+}
+
+// selective picks the requirement that lets through the fewest documents. Both sides of an AND
+// must match so either one is correct.
+func selective(left, right requirement) requirement {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.terms() != right.terms():
+		if left.terms() {
+			return left
+		}
+		return right
+	case len(right) < len(left):
+		return right
+	default:
+		return left
+	}
+}
+
+// terms reports whether every key requires a value
+func (r requirement) terms() bool {
+	for _, k := range r {
+		if k.term == "" {
+			return false
+		}
+	}
+	return len(r) > 0
+}
+
+func sub(in any) *expr.Expression {
+	e, _ := in.(*expr.Expression)
+	return e
+}
+
+// fieldOf returns the field of a leaf. The _source field falls back to every value of documents
+// without it so it can't be required.
+func fieldOf(e *expr.Expression) (string, bool) {
+	left := sub(e.Left)
+	if left == nil {
+		return "", false
+	}
+
+	var field string
+	switch v := left.Left.(type) {
+	case expr.Column:
+		field = string(v)
+	case string:
+		field = v
+	default:
+		return "", false
+	}
+	return field, field != "" && field != eval.SourceField
+}
+
+// keys collects every key the document contains. Fields are flattened into dotted paths the same
+// way eval resolves them.
+func keys(doc map[string]any) map[key]struct{} {
+	out := map[key]struct{}{}
+	collectKeys("", doc, out)
+	return out
+}
+
+func collectKeys(prefix string, doc map[string]any, out map[key]struct{}) {
+	for k, v := range doc {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		collectValue(field, v, out)
+	}
+}
+
+func collectValue(field string, v any, out map[key]struct{}) {
+	switch val := v.(type) {
+	case nil:
+	case map[string]any:
+		out[key{field: field}] = struct{}{}
+		collectKeys(field, val, out)
+	case []any:
+		for _, item := range val {
+			if _, isMap := item.(map[string]any); isMap {
+				// eval doesn't walk into maps inside slices
+				out[key{field: field}] = struct{}{}
+				continue
+			}
+			collectValue(field, item, out)
+		}
+	case []string:
+		for _, s := range val {
+			collectValue(field, s, out)
+		}
+	default:
+		out[key{field: field}] = struct{}{}
+		out[key{field: field, term: toString(val)}] = struct{}{}
+	}
+}
+
+func toString(in any) string {
+	switch v := in.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
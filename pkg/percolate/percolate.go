@@ -0,0 +1,204 @@
+package percolate
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/AlxBystrov/go-lucene/pkg/eval"
+	"github.com/AlxBystrov/go-lucene/pkg/lucene/expr"
+)
+
+// Percolator matches documents against many stored queries. Instead of evaluating every query for
+// every document the queries are indexed by the fields and values a document must contain for them
+// to match, so only the candidate queries are evaluated.
+//
+// A Percolator is safe for concurrent use.
+type Percolator struct {
+	mu sync.RWMutex
+
+	queries map[string]*query
+	// index maps a key to the ids of the queries that require it
+	index map[key]map[string]struct{}
+	// unindexed are the ids of the queries that are a candidate for every document
+	unindexed map[string]struct{}
+
+	workers int
+}
+
+type query struct {
+	e    *expr.Expression
+	keys requirement
+}
+
+// Opt configures a Percolator
+type Opt func(*Percolator)
+
+// WithWorkers sets the number of goroutines MatchBatch uses. It defaults to GOMAXPROCS.
+func WithWorkers(n int) Opt {
+	return func(p *Percolator) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// New creates an empty Percolator
+func New(opts ...Opt) *Percolator {
+	p := &Percolator{
+		queries:   map[string]*query{},
+		index:     map[key]map[string]struct{}{},
+		unindexed: map[string]struct{}{},
+		workers:   runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Len returns the number of registered queries
+func (p *Percolator) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.queries)
+}
+
+// Register stores the query under the id. A query that already has the id is replaced. The
+// expression is validated so that matching documents doesn't fail later on.
+func (p *Percolator) Register(id string, e *expr.Expression) error {
+	if id == "" {
+		return fmt.Errorf("query id must not be empty")
+	}
+	if e == nil {
+		return fmt.Errorf("query %s must not be nil", id)
+	}
+	// explain compiles every leaf without short circuiting so invalid leaves are found
+	if _, err := eval.Explain(e, map[string]any{}); err != nil {
+		return fmt.Errorf("invalid query %s: %w", id, err)
+	}
+
+	q := &query{e: e, keys: extract(e)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.remove(id)
+	p.queries[id] = q
+	if q.keys == nil {
+		p.unindexed[id] = struct{}{}
+		return nil
+	}
+	for _, k := range q.keys {
+		ids, found := p.index[k]
+		if !found {
+			ids = map[string]struct{}{}
+			p.index[k] = ids
+		}
+		ids[id] = struct{}{}
+	}
+	return nil
+}
+
+// Remove deletes the query with the id. It reports whether the query existed.
+func (p *Percolator) Remove(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.remove(id)
+}
+
+func (p *Percolator) remove(id string) bool {
+	q, found := p.queries[id]
+	if !found {
+		return false
+	}
+
+	delete(p.queries, id)
+	delete(p.unindexed, id)
+	for _, k := range q.keys {
+		delete(p.index[k], id)
+		if len(p.index[k]) == 0 {
+			delete(p.index, k)
+		}
+	}
+	return true
+}
+
+// Match returns the sorted ids of the queries that match the document.
+func (p *Percolator) Match(doc map[string]any) ([]string, error) {
+	p.mu.RLock()
+	candidates := p.candidates(doc)
+	p.mu.RUnlock()
+
+	out := []string{}
+	for _, c := range candidates {
+		matched, err := eval.Match(c.e, doc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to match query %s: %w", c.id, err)
+		}
+		if matched {
+			out = append(out, c.id)
+		}
+	}
+	return out, nil
+}
+
+// MatchBatch matches the documents in parallel. The result has the ids of the matching queries at
+// the same position as the document.
+func (p *Percolator) MatchBatch(docs []map[string]any) ([][]string, error) {
+	out := make([][]string, len(docs))
+	errs := make([]error, len(docs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i], errs[i] = p.Match(docs[i])
+			}
+		}()
+	}
+	for i := range docs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+type candidate struct {
+	id string
+	e  *expr.Expression
+}
+
+// candidates returns the queries that could match the document sorted by id. The caller must
+// hold the read lock.
+func (p *Percolator) candidates(doc map[string]any) []candidate {
+	ids := map[string]struct{}{}
+	for id := range p.unindexed {
+		ids[id] = struct{}{}
+	}
+	for k := range keys(doc) {
+		for id := range p.index[k] {
+			ids[id] = struct{}{}
+		}
+	}
+
+	out := make([]candidate, 0, len(ids))
+	for id := range ids {
+		out = append(out, candidate{id: id, e: p.queries[id].e})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].id < out[j].id
+	})
+	return out
+}
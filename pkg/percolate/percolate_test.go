@@ -0,0 +1,200 @@
+package percolate
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene"
+)
+
+var testQueries = map[string]string{
+	"errors":      "level:error",
+	"slow":        "latency:>1000",
+	"checkout":    "service:(checkout OR cart) AND level:error",
+	"not_debug":   "NOT level:debug",
+	"full_text":   "refused",
+	"upstream":    "message:upstream OR http.path:/.*orders/",
+	"wildcard":    "service:check*",
+	"fuzzy":       "message:upstraem~",
+	"missing":     "nope:foo",
+	"must_no_tag": "tags:prod AND -tags:staging",
+}
+
+func testPercolator(t testing.TB, opts ...Opt) *Percolator {
+	t.Helper()
+	p := New(opts...)
+	for id, q := range testQueries {
+		e, err := lucene.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Register(id, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func TestMatch(t *testing.T) {
+	type tc struct {
+		doc  map[string]any
+		want []string
+	}
+
+	tcs := map[string]tc{
+		"checkout_error": {
+			doc: map[string]any{
+				"level":   "error",
+				"service": "checkout",
+				"message": "connection refused by upstream",
+				"tags":    []any{"prod"},
+			},
+			want: []string{"checkout", "errors", "full_text", "fuzzy", "must_no_tag", "not_debug", "upstream", "wildcard"},
+		},
+		"slow_nested": {
+			doc: map[string]any{
+				"level":   "info",
+				"latency": 1500,
+				"http":    map[string]any{"path": "/api/orders"},
+			},
+			want: []string{"not_debug", "slow", "upstream"},
+		},
+		"debug": {
+			doc:  map[string]any{"level": "debug", "tags": []any{"prod", "staging"}},
+			want: []string{},
+		},
+		"empty": {
+			doc:  map[string]any{},
+			want: []string{"not_debug"},
+		},
+	}
+
+	p := testPercolator(t)
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := p.Match(tc.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("\nwant %v\ngot  %v\n", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	p := testPercolator(t)
+
+	p.mu.RLock()
+	candidates := p.candidates(map[string]any{"latency": 5})
+	p.mu.RUnlock()
+
+	got := []string{}
+	for _, c := range candidates {
+		got = append(got, c.id)
+	}
+	// only the query on latency and the ones that can't be indexed need to be evaluated
+	want := []string{"full_text", "not_debug", "slow"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant candidates %v\ngot  %v\n", want, got)
+	}
+}
+
+func TestRegisterAndRemove(t *testing.T) {
+	p := testPercolator(t)
+	doc := map[string]any{"level": "error"}
+
+	e, err := lucene.Parse("level:warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Register("errors", e); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Remove("not_debug") || p.Remove("not_debug") {
+		t.Fatalf("expected only the first remove to find the query")
+	}
+
+	got, err := p.Match(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant %v\ngot  %v\n", want, got)
+	}
+
+	invalid, err := lucene.Parse("a:/b(/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Register("invalid", invalid); err == nil {
+		t.Fatalf("expected an error registering an invalid regexp")
+	}
+	if p.Len() != len(testQueries)-1 {
+		t.Fatalf("expected %d queries but got %d", len(testQueries)-1, p.Len())
+	}
+}
+
+func TestMatchBatchConcurrently(t *testing.T) {
+	p := testPercolator(t, WithWorkers(4))
+
+	docs := []map[string]any{}
+	for i := 0; i < 100; i++ {
+		docs = append(docs, map[string]any{"level": "error", "latency": i * 20})
+	}
+
+	// registering and removing queries while matching must be safe
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			e, _ := lucene.Parse(fmt.Sprintf("latency:%d", i))
+			id := fmt.Sprintf("tmp%d", i)
+			if err := p.Register(id, e); err != nil {
+				t.Error(err)
+			}
+			p.Remove(id)
+		}
+	}()
+
+	got, err := p.MatchBatch(docs)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, ids := range got {
+		want := []string{"errors", "not_debug"}
+		if i*20 > 1000 {
+			want = []string{"errors", "not_debug", "slow"}
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Fatalf("doc %d: want %v but got %v", i, want, ids)
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	p := New()
+	for i := 0; i < 5000; i++ {
+		e, err := lucene.Parse(fmt.Sprintf("service:(svc%d OR svc%d) AND level:error", i, i+1))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := p.Register(fmt.Sprint(i), e); err != nil {
+			b.Fatal(err)
+		}
+	}
+	doc := map[string]any{"service": "svc42", "level": "error"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Match(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package regex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Regexp is a parsed lucene regular expression. Lucene regexps follow the syntax of lucene's
+// RegExp class with every optional feature enabled, the same as a RegexpQuery:
+//
+//	a|b     union
+//	a&b     intersection
+//	~a      complement
+//	@       any string
+//	#       the empty language
+//	<1-100> numeric interval
+//	"abc"   literal string
+//
+// and they always match the whole term. Characters are escaped with a backslash.
+type Regexp struct {
+	pattern string
+	root    node
+}
+
+// String returns the lucene pattern the regexp was parsed from
+func (re *Regexp) String() string {
+	return re.pattern
+}
+
+type node interface {
+	position() int
+}
+
+type (
+	// literal matches the runes as they are
+	literal struct {
+		pos   int
+		runes []rune
+	}
+	// anyChar is the . matching any single character
+	anyChar struct {
+		pos int
+	}
+	// anyString is the @ matching any string
+	anyString struct {
+		pos int
+	}
+	// empty is the # matching nothing at all
+	empty struct {
+		pos int
+	}
+	// class is a character class, either bracketed or a predefined one like \d
+	class struct {
+		pos     int
+		negated bool
+		items   []classItem
+	}
+	concat struct {
+		pos  int
+		subs []node
+	}
+	union struct {
+		pos  int
+		subs []node
+	}
+	intersection struct {
+		pos  int
+		subs []node
+	}
+	complement struct {
+		pos int
+		sub node
+	}
+	// repeat matches its sub expression between min and max times, max is -1 when unbounded
+	repeat struct {
+		pos      int
+		sub      node
+		min, max int
+	}
+	// interval matches the decimal numbers between min and max. With digits set the numbers are
+	// zero padded to that many digits, otherwise any number of leading zeros is accepted.
+	interval struct {
+		pos      int
+		min, max int
+		digits   int
+	}
+	// named is a <name> reference to an automaton provided by the caller
+	named struct {
+		pos  int
+		name string
+	}
+)
+
+// classItem is a range of runes or a predefined class inside a character class
+type classItem struct {
+	lo, hi rune
+	// predefined is one of dDsSwW for the predefined classes
+	predefined rune
+}
+
+func (n literal) position() int      { return n.pos }
+func (n anyChar) position() int      { return n.pos }
+func (n anyString) position() int    { return n.pos }
+func (n empty) position() int        { return n.pos }
+func (n class) position() int        { return n.pos }
+func (n concat) position() int       { return n.pos }
+func (n union) position() int        { return n.pos }
+func (n intersection) position() int { return n.pos }
+func (n complement) position() int   { return n.pos }
+func (n repeat) position() int       { return n.pos }
+func (n interval) position() int     { return n.pos }
+func (n named) position() int        { return n.pos }
+
+// Parse parses a lucene regular expression. The pattern is the part between the slashes of a
+// lucene regexp term.
+func Parse(pattern string) (*Regexp, error) {
+	if !utf8.ValidString(pattern) {
+		return nil, fmt.Errorf("invalid regexp %q: invalid utf8", pattern)
+	}
+
+	p := &parser{pattern: pattern, runes: []rune(pattern)}
+	root, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if p.more() {
+		return nil, p.errorf("unexpected %q", p.runes[p.pos])
+	}
+	return &Regexp{pattern: pattern, root: root}, nil
+}
+
+// parser is a recursive descent parser following the grammar of lucene's RegExp
+type parser struct {
+	pattern string
+	runes   []rune
+	pos     int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid regexp %q: %s at position %d", p.pattern, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *parser) more() bool {
+	return p.pos < len(p.runes)
+}
+
+func (p *parser) peek(chars string) bool {
+	return p.more() && strings.ContainsRune(chars, p.runes[p.pos])
+}
+
+func (p *parser) match(r rune) bool {
+	if p.more() && p.runes[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) next() (rune, error) {
+	if !p.more() {
+		return 0, p.errorf("unexpected end of pattern")
+	}
+	r := p.runes[p.pos]
+	p.pos++
+	return r, nil
+}
+
+func (p *parser) parseUnion() (node, error) {
+	pos := p.pos
+	e, err := p.parseIntersection()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match('|') {
+		return e, nil
+	}
+	rest, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if u, isUnion := rest.(union); isUnion {
+		return union{pos: pos, subs: append([]node{e}, u.subs...)}, nil
+	}
+	return union{pos: pos, subs: []node{e, rest}}, nil
+}
+
+func (p *parser) parseIntersection() (node, error) {
+	pos := p.pos
+	e, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peek("&") {
+		return e, nil
+	}
+	pos = p.pos
+	p.pos++
+	rest, err := p.parseIntersection()
+	if err != nil {
+		return nil, err
+	}
+	return intersection{pos: pos, subs: []node{e, rest}}, nil
+}
+
+func (p *parser) parseConcat() (node, error) {
+	pos := p.pos
+	subs := []node{}
+	for {
+		e, err := p.parseRepeat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, e)
+		if !p.more() || p.peek(")|&") {
+			break
+		}
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return concat{pos: pos, subs: subs}, nil
+}
+
+func (p *parser) parseRepeat() (node, error) {
+	pos := p.pos
+	e, err := p.parseComplement()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek("?*+{") {
+		switch p.runes[p.pos] {
+		case '?':
+			e = repeat{pos: pos, sub: e, min: 0, max: 1}
+		case '*':
+			e = repeat{pos: pos, sub: e, min: 0, max: -1}
+		case '+':
+			e = repeat{pos: pos, sub: e, min: 1, max: -1}
+		case '{':
+			p.pos++
+			min, err := p.parseInt()
+			if err != nil {
+				return nil, err
+			}
+			max := min
+			if p.match(',') {
+				max = -1
+				if p.peek("0123456789") {
+					if max, err = p.parseInt(); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if !p.peek("}") {
+				return nil, p.errorf("expected '}'")
+			}
+			if max >= 0 && max < min {
+				return nil, p.errorf("invalid repetition {%d,%d}", min, max)
+			}
+			e = repeat{pos: pos, sub: e, min: min, max: max}
+		}
+		p.pos++
+	}
+	return e, nil
+}
+
+func (p *parser) parseInt() (int, error) {
+	start := p.pos
+	for p.peek("0123456789") {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.errorf("integer expected")
+	}
+	n, err := strconv.Atoi(string(p.runes[start:p.pos]))
+	if err != nil {
+		return 0, p.errorf("integer %s is too large", string(p.runes[start:p.pos]))
+	}
+	return n, nil
+}
+
+func (p *parser) parseComplement() (node, error) {
+	pos := p.pos
+	if !p.match('~') {
+		return p.parseClass()
+	}
+	sub, err := p.parseComplement()
+	if err != nil {
+		return nil, err
+	}
+	return complement{pos: pos, sub: sub}, nil
+}
+
+func (p *parser) parseClass() (node, error) {
+	pos := p.pos
+	if !p.match('[') {
+		return p.parseSimple()
+	}
+
+	c := class{pos: pos, negated: p.match('^')}
+	for {
+		item, err := p.parseClassItem()
+		if err != nil {
+			return nil, err
+		}
+		c.items = append(c.items, item)
+		if !p.more() || p.peek("]") {
+			break
+		}
+	}
+	if !p.match(']') {
+		return nil, p.errorf("expected ']'")
+	}
+	return c, nil
+}
+
+func (p *parser) parseClassItem() (classItem, error) {
+	if r, isPredefined := p.parsePredefined(); isPredefined {
+		return classItem{predefined: r}, nil
+	}
+
+	lo, err := p.parseChar()
+	if err != nil {
+		return classItem{}, err
+	}
+	if !p.match('-') {
+		return classItem{lo: lo, hi: lo}, nil
+	}
+	hi, err := p.parseChar()
+	if err != nil {
+		return classItem{}, err
+	}
+	if lo > hi {
+		return classItem{}, p.errorf("invalid range %q-%q", lo, hi)
+	}
+	return classItem{lo: lo, hi: hi}, nil
+}
+
+// parsePredefined parses the predefined classes \d \D \s \S \w \W
+func (p *parser) parsePredefined() (rune, bool) {
+	if p.pos+1 < len(p.runes) && p.runes[p.pos] == '\\' && strings.ContainsRune("dDsSwW", p.runes[p.pos+1]) {
+		p.pos += 2
+		return p.runes[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *parser) parseSimple() (node, error) {
+	pos := p.pos
+	switch {
+	case p.match('.'):
+		return anyChar{pos: pos}, nil
+	case p.match('#'):
+		return empty{pos: pos}, nil
+	case p.match('@'):
+		return anyString{pos: pos}, nil
+	case p.match('"'):
+		start := p.pos
+		for p.more() && !p.peek(`"`) {
+			p.pos++
+		}
+		if !p.match('"') {
+			return nil, p.errorf(`expected '"'`)
+		}
+		return literal{pos: pos, runes: p.runes[start : p.pos-1]}, nil
+	case p.match('('):
+		if p.match(')') {
+			return concat{pos: pos}, nil
+		}
+		e, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(')') {
+			return nil, p.errorf("expected ')'")
+		}
+		return e, nil
+	case p.match('<'):
+		start := p.pos
+		for p.more() && !p.peek(">") {
+			p.pos++
+		}
+		if !p.match('>') {
+			return nil, p.errorf("expected '>'")
+		}
+		return p.parseInterval(pos, string(p.runes[start:p.pos-1]))
+	}
+
+	if r, isPredefined := p.parsePredefined(); isPredefined {
+		return class{pos: pos, items: []classItem{{predefined: r}}}, nil
+	}
+	r, err := p.parseChar()
+	if err != nil {
+		return nil, err
+	}
+	return literal{pos: pos, runes: []rune{r}}, nil
+}
+
+// maxIntervalDigits is the longest bound of a numeric interval
+const maxIntervalDigits = 18
+
+// parseInterval parses the inside of <...> which is either a numeric interval or the name of an
+// automaton
+func (p *parser) parseInterval(pos int, s string) (node, error) {
+	i := strings.IndexRune(s, '-')
+	if i < 0 {
+		return named{pos: pos, name: s}, nil
+	}
+
+	smin, smax := s[:i], s[i+1:]
+	min, errMin := strconv.Atoi(smin)
+	max, errMax := strconv.Atoi(smax)
+	if smin == "" || smax == "" || !isDigits(smin) || !isDigits(smax) || errMin != nil || errMax != nil {
+		return nil, fmt.Errorf("invalid regexp %q: interval syntax error at position %d", p.pattern, pos)
+	}
+	// the alternatives of the translation are built on powers of ten which overflow past 18 digits
+	if len(smin) > maxIntervalDigits || len(smax) > maxIntervalDigits {
+		return nil, fmt.Errorf("invalid regexp %q: the interval at position %d has a bound above %d digits", p.pattern, pos, maxIntervalDigits)
+	}
+
+	digits := 0
+	if len(smin) == len(smax) {
+		digits = len(smin)
+	}
+	if min > max {
+		min, max = max, min
+	}
+	return interval{pos: pos, min: min, max: max, digits: digits}, nil
+}
+
+func (p *parser) parseChar() (rune, error) {
+	p.match('\\')
+	return p.next()
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
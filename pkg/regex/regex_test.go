@@ -0,0 +1,175 @@
+package regex
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	type tc struct {
+		input     string
+		wantRE2   string
+		wantPOSIX string
+	}
+
+	tcs := map[string]tc{
+		"literal": {
+			input:     "abc",
+			wantRE2:   "^(?s:abc)$",
+			wantPOSIX: "^(abc)$",
+		},
+		"escaped_specials": {
+			input:     `a\.b\*c\@\&\~`,
+			wantRE2:   `^(?s:a\.b\*c@&~)$`,
+			wantPOSIX: `^(a\.b\*c@&~)$`,
+		},
+		"specials_of_the_target_are_quoted": {
+			input:     `^a$`,
+			wantRE2:   `^(?s:\^a\$)$`,
+			wantPOSIX: `^(\^a\$)$`,
+		},
+		"quoted_string": {
+			input:     `"a.b|c"d`,
+			wantRE2:   `^(?s:a\.b\|cd)$`,
+			wantPOSIX: `^(a\.b\|cd)$`,
+		},
+		"union_in_concat": {
+			input:     "a(b|c)d",
+			wantRE2:   "^(?s:a(?:b|c)d)$",
+			wantPOSIX: "^(a(b|c)d)$",
+		},
+		"repeats": {
+			input:     "ab*c+d?(ef){2}g{1,}h{2,3}",
+			wantRE2:   "^(?s:ab*c+d?(?:ef){2}g+h{2,3})$",
+			wantPOSIX: "^(ab*c+d?(ef){2}g+h{2,3})$",
+		},
+		"nested_repeats": {
+			input:     "a**",
+			wantRE2:   "^(?s:(?:a*)*)$",
+			wantPOSIX: "^((a*)*)$",
+		},
+		"any_string": {
+			input:     "foo@",
+			wantRE2:   "^(?s:foo.*)$",
+			wantPOSIX: "^(foo.*)$",
+		},
+		"classes": {
+			input:     `[a-c\]x\-][^0-9]`,
+			wantRE2:   `^(?s:[a-c\]x\-][^0-9])$`,
+			wantPOSIX: `^([a-c\]x\-][^0-9])$`,
+		},
+		"predefined_classes": {
+			input:     `\d\W[\s_]`,
+			wantRE2:   "^(?s:[0-9][^a-zA-Z_0-9][ \\t\\n\\r_])$",
+			wantPOSIX: "^([0-9][^a-zA-Z_0-9][ \\t\\n\\r_])$",
+		},
+		"fixed_interval": {
+			input:     "<01-12>",
+			wantRE2:   "^(?s:(?:0[1-9]|1[0-2]))$",
+			wantPOSIX: "^((0[1-9]|1[0-2]))$",
+		},
+		"interval": {
+			input:     "id<5-120>",
+			wantRE2:   "^(?s:id0*(?:[5-9]|[1-9][0-9]|1(?:[0-1][0-9]|20)))$",
+			wantPOSIX: "^(id0*([5-9]|[1-9][0-9]|1([0-1][0-9]|20)))$",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := ToRE2(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.wantRE2 {
+				t.Fatalf("\nwant RE2   %s\ngot        %s\n", tc.wantRE2, got)
+			}
+			if _, err := regexp.Compile(got); err != nil {
+				t.Fatalf("translated an invalid RE2 pattern: %s", err)
+			}
+
+			got, err = ToPOSIX(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.wantPOSIX {
+				t.Fatalf("\nwant POSIX %s\ngot        %s\n", tc.wantPOSIX, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	tcs := map[string]string{
+		"a&b":                     "the intersection & at position 1 has no equivalent",
+		"~a":                      "the complement ~ at position 0 has no equivalent",
+		"a#":                      "the empty language # at position 1 has no equivalent",
+		"<ip>":                    "the named automaton <ip> at position 0 has no equivalent",
+		`[a\D]`:                   `the negated class \D inside a character class`,
+		"a{2000}":                 "a repetition above 1000",
+		"a(b":                     "expected ')' at position 3",
+		"[ab":                     "expected ']'",
+		`"ab`:                     `expected '"'`,
+		"a{,2}":                   "integer expected",
+		"a{3,2}":                  "invalid repetition {3,2}",
+		"[z-a]":                   "invalid range",
+		"<1-a>":                   "interval syntax error",
+		"a|":                      "unexpected end of pattern",
+		"a)":                      "unexpected ')' at position 1",
+		"a\\":                     "unexpected end of pattern",
+		"<1-5>{300}":              "a repetition above 255",
+		"<0-9223372036854775807>": "a bound above 18 digits",
+		"<5-1000000000000000000>": "a bound above 18 digits",
+	}
+
+	for input, want := range tcs {
+		t.Run(input, func(t *testing.T) {
+			_, errRE2 := ToRE2(input)
+			_, errPOSIX := ToPOSIX(input)
+			if errPOSIX == nil || !strings.Contains(errPOSIX.Error(), want) {
+				if errRE2 == nil || !strings.Contains(errRE2.Error(), want) {
+					t.Fatalf("expected error [%s] but got [%v] and [%v]", want, errRE2, errPOSIX)
+				}
+			}
+		})
+	}
+}
+
+func TestIntervalMatches(t *testing.T) {
+	tcs := map[string]func(s string) bool{
+		"<0-255>": func(s string) bool {
+			n, err := strconv.Atoi(s)
+			return err == nil && isDigits(s) && n <= 255
+		},
+		"<007-120>": func(s string) bool {
+			n, err := strconv.Atoi(s)
+			return err == nil && isDigits(s) && len(s) == 3 && n >= 7 && n <= 120
+		},
+		"<99-9>": func(s string) bool {
+			n, err := strconv.Atoi(s)
+			return err == nil && isDigits(s) && n >= 9 && n <= 99
+		},
+	}
+
+	for input, want := range tcs {
+		t.Run(input, func(t *testing.T) {
+			pattern, err := ToRE2(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			re := regexp.MustCompile(pattern)
+
+			candidates := []string{"", "a", "-1", "0000", "1000"}
+			for n := 0; n < 1000; n++ {
+				candidates = append(candidates, strconv.Itoa(n), "0"+strconv.Itoa(n), pad(n, 3))
+			}
+			for _, s := range candidates {
+				if got := re.MatchString(s); got != want(s) {
+					t.Fatalf("%s matching %q: want %v got %v", pattern, s, want(s), got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package regex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dialect describes the regular expression syntax a lucene regexp is translated to
+type dialect struct {
+	name string
+	// open starts a group, groups are closed with )
+	open string
+	// maxRepeat is the largest count a {n,m} repetition may have
+	maxRepeat int
+	// anchor wraps the translated pattern so it matches the whole value
+	anchor func(s string) string
+}
+
+// re2 is the syntax of RE2, used by ClickHouse match() and the go regexp package. The s flag makes
+// . match new lines like it does in lucene, which ClickHouse does by default anyway.
+var re2 = dialect{
+	name:      "RE2",
+	open:      "(?:",
+	maxRepeat: 1000,
+	anchor: func(s string) string {
+		return "^(?s:" + s + ")$"
+	},
+}
+
+// posix is the POSIX extended syntax, used by the PostgreSQL ~ and ~* operators. PostgreSQL's .
+// matches new lines unless the pattern asks for newline sensitive matching.
+var posix = dialect{
+	name:      "POSIX",
+	open:      "(",
+	maxRepeat: 255,
+	anchor: func(s string) string {
+		return "^(" + s + ")$"
+	},
+}
+
+// RE2 translates the regexp into an anchored RE2 pattern for ClickHouse match() and the go regexp
+// package.
+func (re *Regexp) RE2() (string, error) {
+	return re.translate(re2)
+}
+
+// POSIX translates the regexp into an anchored POSIX extended pattern for the PostgreSQL ~
+// operator.
+func (re *Regexp) POSIX() (string, error) {
+	return re.translate(posix)
+}
+
+// ToRE2 parses a lucene regexp and translates it into an anchored RE2 pattern
+func ToRE2(pattern string) (string, error) {
+	re, err := Parse(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.RE2()
+}
+
+// ToPOSIX parses a lucene regexp and translates it into an anchored POSIX extended pattern
+func ToPOSIX(pattern string) (string, error) {
+	re, err := Parse(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.POSIX()
+}
+
+func (re *Regexp) translate(d dialect) (string, error) {
+	t := translator{pattern: re.pattern, dialect: d}
+	s, err := t.translate(re.root)
+	if err != nil {
+		return "", err
+	}
+	return d.anchor(s), nil
+}
+
+type translator struct {
+	pattern string
+	dialect
+}
+
+// unsupported reports a lucene construct that has no equivalent in the dialect
+func (t translator) unsupported(n node, construct string) error {
+	return fmt.Errorf(
+		"unable to translate the regexp %q to %s: %s at position %d has no equivalent",
+		t.pattern,
+		t.name,
+		construct,
+		n.position(),
+	)
+}
+
+func (t translator) translate(n node) (string, error) {
+	switch v := n.(type) {
+	case literal:
+		var sb strings.Builder
+		for _, r := range v.runes {
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+		return sb.String(), nil
+	case anyChar:
+		return ".", nil
+	case anyString:
+		return ".*", nil
+	case class:
+		return t.class(v)
+	case concat:
+		var sb strings.Builder
+		for _, sub := range v.subs {
+			s, err := t.translate(sub)
+			if err != nil {
+				return "", err
+			}
+			if _, isUnion := sub.(union); isUnion {
+				s = t.open + s + ")"
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+	case union:
+		alternatives := []string{}
+		for _, sub := range v.subs {
+			s, err := t.translate(sub)
+			if err != nil {
+				return "", err
+			}
+			alternatives = append(alternatives, s)
+		}
+		return strings.Join(alternatives, "|"), nil
+	case repeat:
+		return t.repeat(v)
+	case interval:
+		return t.interval(v), nil
+	case empty:
+		return "", t.unsupported(v, "the empty language #")
+	case intersection:
+		return "", t.unsupported(v, "the intersection &")
+	case complement:
+		return "", t.unsupported(v, "the complement ~")
+	case named:
+		return "", t.unsupported(v, fmt.Sprintf("the named automaton <%s>", v.name))
+	default:
+		return "", fmt.Errorf("unable to translate the regexp %q: unknown node %T", t.pattern, n)
+	}
+}
+
+func (t translator) repeat(v repeat) (string, error) {
+	if v.min > t.maxRepeat || v.max > t.maxRepeat {
+		return "", t.unsupported(v, fmt.Sprintf("a repetition above %d", t.maxRepeat))
+	}
+
+	s, err := t.translate(v.sub)
+	if err != nil {
+		return "", err
+	}
+	if !isAtom(v.sub) {
+		s = t.open + s + ")"
+	}
+
+	switch {
+	case v.min == 0 && v.max == 1:
+		return s + "?", nil
+	case v.min == 0 && v.max < 0:
+		return s + "*", nil
+	case v.min == 1 && v.max < 0:
+		return s + "+", nil
+	case v.max < 0:
+		return fmt.Sprintf("%s{%d,}", s, v.min), nil
+	case v.min == v.max:
+		return fmt.Sprintf("%s{%d}", s, v.min), nil
+	default:
+		return fmt.Sprintf("%s{%d,%d}", s, v.min, v.max), nil
+	}
+}
+
+// isAtom checks whether the node translates to something a repetition applies to as a whole
+func isAtom(n node) bool {
+	switch v := n.(type) {
+	case literal:
+		return len(v.runes) == 1
+	case anyChar, class:
+		return true
+	default:
+		return false
+	}
+}
+
+// predefinedRanges are the ranges of lucene's predefined classes, which are ascii only unlike the
+// ones of most regexp engines
+var predefinedRanges = map[rune]string{
+	'd': "0-9",
+	's': ` \t\n\r`,
+	'w': "a-zA-Z_0-9",
+}
+
+func (t translator) class(v class) (string, error) {
+	// a lone predefined class keeps its own negation
+	if len(v.items) == 1 && v.items[0].predefined != 0 && !v.negated {
+		p := v.items[0].predefined
+		lower := strings.ToLower(string(p))
+		if lower != string(p) {
+			return "[^" + predefinedRanges[rune(lower[0])] + "]", nil
+		}
+		return "[" + predefinedRanges[p] + "]", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	if v.negated {
+		sb.WriteString("^")
+	}
+	for _, item := range v.items {
+		if item.predefined != 0 {
+			ranges, found := predefinedRanges[item.predefined]
+			if !found {
+				return "", t.unsupported(v, fmt.Sprintf(`the negated class \%c inside a character class`, item.predefined))
+			}
+			sb.WriteString(ranges)
+			continue
+		}
+
+		sb.WriteString(classChar(item.lo))
+		if item.hi != item.lo {
+			sb.WriteString("-")
+			sb.WriteString(classChar(item.hi))
+		}
+	}
+	sb.WriteString("]")
+	return sb.String(), nil
+}
+
+// classChar escapes the characters that are special inside a character class
+func classChar(r rune) string {
+	switch r {
+	case '\\', ']', '[', '^', '-':
+		return `\` + string(r)
+	case '\n':
+		return `\n`
+	case '\t':
+		return `\t`
+	case '\r':
+		return `\r`
+	default:
+		return string(r)
+	}
+}
+
+// interval matches the decimal numbers between min and max like lucene's decimal interval
+// automaton.
+func (t translator) interval(v interval) string {
+	if v.digits > 0 {
+		return t.digitRange(pad(v.min, v.digits), pad(v.max, v.digits))
+	}
+
+	// without a fixed number of digits every length is matched on its own and leading zeros are
+	// accepted
+	alternatives := []string{}
+	for lo := v.min; lo <= v.max; {
+		digits := len(strconv.Itoa(lo))
+		hi := v.max
+		if top := pow10(digits) - 1; top < hi {
+			hi = top
+		}
+		alternatives = append(alternatives, t.digitRange(strconv.Itoa(lo), strconv.Itoa(hi)))
+		lo = hi + 1
+	}
+	if len(alternatives) == 1 {
+		return "0*" + alternatives[0]
+	}
+	return "0*" + t.open + strings.Join(alternatives, "|") + ")"
+}
+
+// digitRange matches the strings of digits between lo and hi which have the same length. Alternatives
+// are always grouped so the result can be prefixed.
+func (t translator) digitRange(lo, hi string) string {
+	switch {
+	case lo == hi:
+		return lo
+	case strings.Trim(lo, "0") == "" && strings.Trim(hi, "9") == "":
+		return anyDigits(len(lo))
+	case lo[0] == hi[0]:
+		return lo[:1] + (t.digitRange(lo[1:], hi[1:]))
+	case len(lo) == 1:
+		return "[" + lo + "-" + hi + "]"
+	}
+
+	rest := len(lo) - 1
+	nines, zeros := strings.Repeat("9", rest), strings.Repeat("0", rest)
+
+	alternatives := []string{}
+	first := lo[0]
+	if lo[1:] != zeros {
+		alternatives = append(alternatives, lo[:1]+(t.digitRange(lo[1:], nines)))
+		first++
+	}
+	last := hi[0]
+	if hi[1:] != nines {
+		last--
+	}
+	switch {
+	case first == last:
+		alternatives = append(alternatives, string(first)+anyDigits(rest))
+	case first < last:
+		alternatives = append(alternatives, "["+string(first)+"-"+string(last)+"]"+anyDigits(rest))
+	}
+	if hi[1:] != nines {
+		alternatives = append(alternatives, hi[:1]+(t.digitRange(zeros, hi[1:])))
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return t.open + strings.Join(alternatives, "|") + ")"
+}
+
+func anyDigits(n int) string {
+	if n == 1 {
+		return "[0-9]"
+	}
+	return fmt.Sprintf("[0-9]{%d}", n)
+}
+
+func pad(n, digits int) string {
+	return fmt.Sprintf("%0*d", digits, n)
+}
+
+func pow10(n int) int {
+	out := 1
+	for i := 0; i < n; i++ {
+		out *= 10
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package wildcard
+
+import (
+	"regexp"
+	"strings"
+)
+
+type kind int
+
+const (
+	text kind = iota
+	// anyString is the * matching any number of characters
+	anyString
+	// anyChar is the ? matching a single character
+	anyChar
+)
+
+type part struct {
+	kind kind
+	text string
+}
+
+// Pattern is a parsed lucene wildcard term. * matches any number of characters, ? matches a
+// single character and a backslash escapes the character after it so \* and \? match themselves.
+// Every other character is matched as it is, including the ones that are special to the pattern
+// syntax the term is translated to like % and _ in LIKE.
+type Pattern struct {
+	parts []part
+}
+
+// Parse parses a lucene wildcard term
+func Parse(s string) Pattern {
+	p := Pattern{}
+	var sb strings.Builder
+	flush := func() {
+		if sb.Len() > 0 {
+			p.parts = append(p.parts, part{kind: text, text: sb.String()})
+			sb.Reset()
+		}
+	}
+
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			flush()
+			p.parts = append(p.parts, part{kind: anyString})
+		case r == '?':
+			flush()
+			p.parts = append(p.parts, part{kind: anyChar})
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	// a trailing backslash has nothing to escape and matches itself
+	if escaped {
+		sb.WriteRune('\\')
+	}
+	flush()
+	return p
+}
+
+// HasWildcards checks whether the pattern has any unescaped * or ?
+func (p Pattern) HasWildcards() bool {
+	for _, part := range p.parts {
+		if part.kind != text {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefix returns the text before the * of a pattern that is a prefix query, one where the only
+// wildcard is a trailing *
+func (p Pattern) Prefix() (string, bool) {
+	if len(p.parts) != 2 || p.parts[0].kind != text || p.parts[1].kind != anyString {
+		return "", false
+	}
+	return p.parts[0].text, true
+}
+
+// Like translates the pattern into a LIKE (or ILIKE) pattern where %, _ and the escape character
+// itself are escaped with the escape character. Dialects without a default escape character need
+// an ESCAPE clause naming it.
+func (p Pattern) Like(escape rune) string {
+	e := string(escape)
+	return p.like(strings.NewReplacer(e, e+e, "%", e+"%", "_", e+"_"))
+}
+
+// similarSymbols are the characters that are special to SIMILAR TO on top of % and _
+const similarSymbols = "|*+?{}()[]"
+
+// SimilarTo translates the pattern into a SIMILAR TO pattern where every character SIMILAR TO
+// treats as a regular expression operator is escaped with the escape character as well.
+func (p Pattern) SimilarTo(escape rune) string {
+	e := string(escape)
+	pairs := []string{e, e + e, "%", e + "%", "_", e + "_"}
+	for _, r := range similarSymbols {
+		pairs = append(pairs, string(r), e+string(r))
+	}
+	return p.like(strings.NewReplacer(pairs...))
+}
+
+// like renders * and ? as % and _ and escapes the text with the replacer
+func (p Pattern) like(replacer *strings.Replacer) string {
+	var sb strings.Builder
+	for _, part := range p.parts {
+		switch part.kind {
+		case anyString:
+			sb.WriteString("%")
+		case anyChar:
+			sb.WriteString("_")
+		default:
+			sb.WriteString(replacer.Replace(part.text))
+		}
+	}
+	return sb.String()
+}
+
+// globReplacer escapes the characters that are special to GLOB by wrapping them in a character
+// class since GLOB has no escape character
+var globReplacer = strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]")
+
+// Glob translates the pattern into a GLOB pattern as used by SQLite
+func (p Pattern) Glob() string {
+	var sb strings.Builder
+	for _, part := range p.parts {
+		switch part.kind {
+		case anyString:
+			sb.WriteString("*")
+		case anyChar:
+			sb.WriteString("?")
+		default:
+			sb.WriteString(globReplacer.Replace(part.text))
+		}
+	}
+	return sb.String()
+}
+
+// RE2 translates the pattern into an anchored RE2 regexp for ClickHouse match() and the go regexp
+// package.
+func (p Pattern) RE2() string {
+	return "^(?s:" + p.regexp() + ")$"
+}
+
+// RE2Spans translates the pattern into an RE2 regexp that finds the parts of a value the pattern
+// matches without its *. Every run of the pattern between two * is a group, the * between them
+// match lazily and the sides that don't start or end with a * are anchored. It is empty when the
+// pattern only has *.
+func (p Pattern) RE2Spans() string {
+	parts := p.parts
+	for len(parts) > 0 && parts[0].kind == anyString {
+		parts = parts[1:]
+	}
+	for len(parts) > 0 && parts[len(parts)-1].kind == anyString {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if p.parts[0].kind != anyString {
+		sb.WriteString("^")
+	}
+	sb.WriteString("(?s:(")
+	for _, part := range parts {
+		switch part.kind {
+		case anyString:
+			sb.WriteString(").*?(")
+		case anyChar:
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(part.text))
+		}
+	}
+	sb.WriteString("))")
+	if p.parts[len(p.parts)-1].kind != anyString {
+		sb.WriteString("$")
+	}
+	return sb.String()
+}
+
+// POSIX translates the pattern into an anchored POSIX extended regexp for the PostgreSQL ~
+// operator.
+func (p Pattern) POSIX() string {
+	return "^(" + p.regexp() + ")$"
+}
+
+func (p Pattern) regexp() string {
+	var sb strings.Builder
+	for _, part := range p.parts {
+		switch part.kind {
+		case anyString:
+			sb.WriteString(".*")
+		case anyChar:
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(part.text))
+		}
+	}
+	return sb.String()
+}
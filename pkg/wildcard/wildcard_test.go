@@ -0,0 +1,134 @@
+package wildcard
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	type tc struct {
+		input     string
+		wantLike  string
+		wantSim   string
+		wantGlob  string
+		wantRE2   string
+		wantPOSIX string
+	}
+
+	tcs := map[string]tc{
+		"wildcards": {
+			input:     "a*b?c",
+			wantLike:  "a%b_c",
+			wantSim:   "a%b_c",
+			wantGlob:  "a*b?c",
+			wantRE2:   "^(?s:a.*b.c)$",
+			wantPOSIX: "^(a.*b.c)$",
+		},
+		"like_symbols_are_escaped": {
+			input:     "50%_off*",
+			wantLike:  `50\%\_off%`,
+			wantSim:   `50\%\_off%`,
+			wantGlob:  "50%_off*",
+			wantRE2:   "^(?s:50%_off.*)$",
+			wantPOSIX: "^(50%_off.*)$",
+		},
+		"escaped_wildcards": {
+			input:     `a\*b\?*`,
+			wantLike:  "a*b?%",
+			wantSim:   `a\*b\?%`,
+			wantGlob:  "a[*]b[?]*",
+			wantRE2:   `^(?s:a\*b\?.*)$`,
+			wantPOSIX: `^(a\*b\?.*)$`,
+		},
+		"escape_character": {
+			input:     `a\\b\`,
+			wantLike:  `a\\b\\`,
+			wantSim:   `a\\b\\`,
+			wantGlob:  `a\b\`,
+			wantRE2:   `^(?s:a\\b\\)$`,
+			wantPOSIX: `^(a\\b\\)$`,
+		},
+		"regexp_symbols": {
+			input:     "v1.[0]+(x)",
+			wantLike:  "v1.[0]+(x)",
+			wantSim:   `v1.\[0\]\+\(x\)`,
+			wantGlob:  "v1.[[]0]+(x)",
+			wantRE2:   `^(?s:v1\.\[0\]\+\(x\))$`,
+			wantPOSIX: `^(v1\.\[0\]\+\(x\))$`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			p := Parse(tc.input)
+			if got := p.Like('\\'); got != tc.wantLike {
+				t.Fatalf("\nwant LIKE  %s\ngot        %s\n", tc.wantLike, got)
+			}
+			if got := p.SimilarTo('\\'); got != tc.wantSim {
+				t.Fatalf("\nwant SIMILAR %s\ngot          %s\n", tc.wantSim, got)
+			}
+			if got := p.Glob(); got != tc.wantGlob {
+				t.Fatalf("\nwant GLOB  %s\ngot        %s\n", tc.wantGlob, got)
+			}
+			if got := p.RE2(); got != tc.wantRE2 {
+				t.Fatalf("\nwant RE2   %s\ngot        %s\n", tc.wantRE2, got)
+			}
+			if got := p.POSIX(); got != tc.wantPOSIX {
+				t.Fatalf("\nwant POSIX %s\ngot        %s\n", tc.wantPOSIX, got)
+			}
+		})
+	}
+}
+
+func TestLikeEscapeCharacter(t *testing.T) {
+	got := Parse(`100%!_*`).Like('!')
+	if want := "100!%!!!_%"; got != want {
+		t.Fatalf("\nwant %s\ngot  %s\n", want, got)
+	}
+}
+
+func TestRE2Matches(t *testing.T) {
+	re := regexp.MustCompile(Parse(`50%_off\*?`).RE2())
+	for s, want := range map[string]bool{
+		"50%_off*!": true,
+		"50%_off*":  false,
+		"50%xoff*!": false,
+		"50%_offx!": false,
+	} {
+		if got := re.MatchString(s); got != want {
+			t.Fatalf("matching %q: want %v got %v", s, want, got)
+		}
+	}
+}
+
+func TestHasWildcards(t *testing.T) {
+	if !Parse("a*").HasWildcards() || Parse(`a\*\?`).HasWildcards() {
+		t.Fatalf("expected only unescaped wildcards to count")
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tcs := map[string]string{"abc*": "abc", `a\*b*`: "a*b", "a*b": "", "*": "", "a?*": "", "abc": ""}
+	for input, want := range tcs {
+		got, ok := Parse(input).Prefix()
+		if ok != (want != "") || got != want {
+			t.Fatalf("%s: want prefix %q but got %q (%v)", input, want, got, ok)
+		}
+	}
+}
+
+func TestRE2Spans(t *testing.T) {
+	tcs := map[string]string{
+		"check*":    `^(?s:(check))`,
+		"*out":      `(?s:(out))$`,
+		"*a?b*c*":   `(?s:(a.b).*?(c))`,
+		`a.b\*c`:    `^(?s:(a\.b\*c))$`,
+		"**":        "",
+		"time*out*": `^(?s:(time).*?(out))`,
+	}
+	for input, want := range tcs {
+		if got := Parse(input).RE2Spans(); got != want {
+			t.Fatalf("%s: want %s but got %s", input, want, got)
+		}
+	}
+}
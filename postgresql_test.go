@@ -4,7 +4,8 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/AlxBystrov/go-lucene/pkg/driverclick"
+	"github.com/AlxBystrov/go-lucene/pkg/driver"
+	pg_query "github.com/pganalyze/pg_query_go/v4"
 )
 
 func TestPostgresSQLEndToEnd(t *testing.T) {
@@ -67,9 +68,21 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 			input: "a:{* TO 5}",
 			want:  `"a" < 5`,
 		},
+		"float_range": {
+			input: "a:[0.5 TO 2.25]",
+			want:  `"a" >= 0.5 AND "a" <= 2.25`,
+		},
+		"unbounded_range": {
+			input: "a:[* TO *]",
+			want:  `"a" IS NOT NULL`,
+		},
+		"range_over_strings_with_commas": {
+			input: `a:["a, b" TO "c's"]`,
+			want:  `"a" >= 'a, b' AND "a" <= 'c''s'`,
+		},
 		"range_over_strings": {
 			input: "a:{foo TO bar}",
-			want:  `"a" BETWEEN 'foo' AND 'bar'`,
+			want:  `"a" > 'foo' AND "a" < 'bar'`,
 		},
 		"basic_fuzzy": {
 			input: "b AND a~",
@@ -89,15 +102,35 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 		},
 		"regexp": {
 			input: "a:/b [c]/",
-			want:  `"a" ~ '/b [c]/'`,
+			want:  `"a" ~ '^(b [c])$'`,
 		},
 		"regexp_with_keywords": {
+			input: `a:/b "[c]"/`,
+			want:  `"a" ~ '^(b \[c\])$'`,
+		},
+		"regexp_with_unclosed_quote": {
 			input: `a:/b "[c]/`,
-			want:  `"a" ~ '/b "[c]/'`,
+			err:   `expected '"'`,
 		},
 		"regexp_with_escaped_chars": {
 			input: `url:/example.com\/foo\/bar\/.*/`,
-			want:  `"url" ~ '/example.com\/foo\/bar\/.*/'`,
+			want:  `"url" ~ '^(example.com/foo/bar/.*)$'`,
+		},
+		"regexp_lucene_syntax": {
+			input: `status:/5<0-3>\d|@err/`,
+			want:  `"status" ~ '^(5[0-3][0-9]|.*err)$'`,
+		},
+		"regexp_complement_is_an_error": {
+			input: `a:/~b/`,
+			err:   "the complement ~ at position 0 has no equivalent",
+		},
+		"wild_escapes_similar_symbols": {
+			input: `path:50%_off\(1\)*`,
+			want:  `"path" SIMILAR TO '50\%\_off\(1\)%'`,
+		},
+		"wild_with_escaped_wildcards": {
+			input: `a:b\*c?`,
+			want:  `"a" SIMILAR TO 'b\*c_'`,
 		},
 		"basic_default_AND": {
 			input: "a b",
@@ -133,7 +166,7 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 		},
 		"range_operator_exclusive": {
 			input: `a:{"ab" TO "az"}`,
-			want:  `"a" BETWEEN 'ab' AND 'az'`,
+			want:  `"a" > 'ab' AND "a" < 'az'`,
 		},
 		"range_operator_exclusive_unbound": {
 			input: `a:{2 TO *}`,
@@ -231,6 +264,14 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 			input: "1a:b",
 			want:  `"1a" = 'b'`,
 		},
+		"boolean_query": {
+			input: "+a:b c:d -e:f",
+			want:  `("a" = 'b') AND (NOT("e" = 'f'))`,
+		},
+		"boolean_query_one_should_without_must": {
+			input: "a:b c:d -e:f",
+			want:  `(("a" = 'b') OR ("c" = 'd')) AND (NOT("e" = 'f'))`,
+		},
 	}
 
 	for name, tc := range tcs {
@@ -240,7 +281,7 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			got, err := driverclick.NewClickhouseDriver().Render(expr)
+			got, err := driver.NewPostgresDriver().Render(expr)
 			if err != nil {
 				// if we got an expect error then we are fine
 				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
@@ -256,6 +297,10 @@ func TestPostgresSQLEndToEnd(t *testing.T) {
 			if got != tc.want {
 				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, expr)
 			}
+
+			if _, err := pg_query.Parse("SELECT * FROM test WHERE " + got); err != nil {
+				t.Fatalf("rendered invalid sql %s: %v", got, err)
+			}
 		})
 	}
 }
@@ -0,0 +1,145 @@
+package lucene
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlxBystrov/go-lucene/pkg/driver"
+)
+
+func TestSQLiteEndToEnd(t *testing.T) {
+	type tc struct {
+		input string
+		opts  []driver.SQLiteOpt
+		want  string
+		err   string
+	}
+
+	fts := driver.WithFTS5(driver.FTS5{Table: "logs_fts", Columns: []string{"message"}})
+
+	tcs := map[string]tc{
+		"basic_equal": {
+			input: "a:b",
+			want:  `"a" = 'b'`,
+		},
+		"double_quote_in_column_name": {
+			input: `a\"b:c`,
+			want:  `"a""b" = 'c'`,
+		},
+		"wild_glob": {
+			input: "path:api*",
+			want:  `"path" GLOB 'api*'`,
+		},
+		"wild_glob_escapes_glob_symbols": {
+			input: `path:v1\[0\]*`,
+			want:  `"path" GLOB 'v1[[]0]*'`,
+		},
+		"wild_glob_with_escaped_wildcards": {
+			input: `a:b\*c?`,
+			want:  `"a" GLOB 'b[*]c?'`,
+		},
+		"wild_like": {
+			input: "path:50%_off*",
+			opts:  []driver.SQLiteOpt{driver.WithLikeWildcards()},
+			want:  `"path" LIKE '50\%\_off%' ESCAPE '\'`,
+		},
+		"regexp": {
+			input: `a:/b[0-9]+/`,
+			want:  `"a" REGEXP '^(?s:b[0-9]+)$'`,
+		},
+		"range": {
+			input: "a:[1 TO 5]",
+			want:  `"a" >= 1 AND "a" <= 5`,
+		},
+		"free_text_without_fts": {
+			input: "foo AND a:b",
+			want:  `'foo' AND ("a" = 'b')`,
+		},
+		"fts_term": {
+			input: "timeout",
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '"timeout"')`,
+		},
+		"fts_boolean": {
+			input: `timeout AND NOT retry OR "connection reset"`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '(("timeout") NOT ("retry")) OR ("connection reset")')`,
+		},
+		"fts_prefix_and_near": {
+			input: `conn* AND "upstream refused"~5`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '("conn" *) AND (NEAR("upstream" "refused", 5))')`,
+		},
+		"fts_indexed_column": {
+			input: `message:(refused OR reset) AND message:"it's down"`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '("message" : ("refused" OR "reset")) AND ("message" : "it''s down")')`,
+		},
+		"fts_mixed_with_columns": {
+			input: `timeout AND level:error`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '"timeout"') AND ("level" = 'error')`,
+		},
+		"fts_lone_negation": {
+			input: `NOT timeout`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `NOT(rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '"timeout"'))`,
+		},
+		"fts_boolean_query": {
+			input: `+timeout -retry level:error`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `rowid IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '("timeout") NOT ("retry")')`,
+		},
+		"fts_custom_key": {
+			input: "timeout",
+			opts:  []driver.SQLiteOpt{driver.WithFTS5(driver.FTS5{Table: "logs_fts", Key: "log_id"})},
+			want:  `log_id IN (SELECT rowid FROM "logs_fts" WHERE "logs_fts" MATCH '"timeout"')`,
+		},
+		"fts_unindexed_column_stays_sql": {
+			input: `level:err*`,
+			opts:  []driver.SQLiteOpt{fts},
+			want:  `"level" GLOB 'err*'`,
+		},
+		"fts_free_text_regexp": {
+			input: `/time.*/`,
+			opts:  []driver.SQLiteOpt{fts},
+			err:   "unable to match the regexp",
+		},
+		"fts_free_text_wildcard": {
+			input: `ti?eout`,
+			opts:  []driver.SQLiteOpt{fts},
+			err:   "only prefixes like abc* are supported",
+		},
+		"fts_fuzzy_term": {
+			input: `timeout~2`,
+			opts:  []driver.SQLiteOpt{fts},
+			err:   "unable to match the fuzzy term",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			expr, err := Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := driver.NewSQLiteDriver(tc.opts...).Render(expr)
+			if err != nil {
+				// if we got an expect error then we are fine
+				if tc.err != "" && strings.Contains(err.Error(), tc.err) {
+					return
+				}
+				t.Fatalf("unexpected error rendering expression: %v", err)
+			}
+
+			if tc.err != "" {
+				t.Fatalf("\nexpected error [%s]\ngot: %s", tc.err, got)
+			}
+
+			if got != tc.want {
+				t.Fatalf("\nwant %s\ngot  %s\nparsed expression: %#v\n", tc.want, got, expr)
+			}
+		})
+	}
+}